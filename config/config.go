@@ -18,6 +18,44 @@ type Config struct {
 	RedisAddr  string
 	JWTSecret  string
 	Port       string
+
+	SigningSecret string // 退订链接、评论表单令牌等 HMAC 签名密钥，与 JWT 密钥分开，必须配置
+
+	// 站点信息，用于生成绝对链接和分享页元数据
+	SiteURL        string
+	SiteName       string
+	SPAArticlePath string
+
+	TrustedProxies string // 逗号分隔的反向代理地址或网段，只有来自这些地址的请求才采信 X-Real-IP
+
+	SpamBlocklist string // 逗号分隔的评论屏蔽词，追加到内置列表
+
+	// 限流规则，格式为 "次数/时间窗口"，如 "5/1m"
+	RateLimitComment    string
+	RateLimitView       string
+	RateLimitRegister   string
+	RateLimitFetch      string
+	RateLimitReaction   string
+	RateLimitWebmention string
+	RateLimitReport     string
+
+	// 举报自动隐藏
+	ReportHideThreshold string // 计入的举报人数达到该值时自动隐藏内容，0 表示关闭
+	ReportMinAccountAge string // 注册满该时长的登录用户才计入举报人数
+
+	// 邮件通知
+	MailDriver         string // file, smtp, none
+	MailDir            string // file 驱动的输出目录
+	MailFrom           string
+	SMTPAddr           string
+	SMTPUsername       string
+	SMTPPassword       string
+	MailInstantLimit   string // 每个收件人每个摘要周期内最多即时发送的邮件数
+	MailDigestInterval string // 摘要发送周期
+
+	ViewRetentionDays string // 原始浏览记录保留天数，0 表示永久保留
+
+	SearchIndexPath string // 全文检索索引目录，为空时只使用数据库搜索
 }
 
 func LoadConfig() *Config {
@@ -30,6 +68,40 @@ func LoadConfig() *Config {
 		RedisAddr:  getEnv("REDIS_ADDR", "localhost:6379"),
 		JWTSecret:  getEnv("JWT_SECRET", ""),
 		Port:       getEnv("PORT", "8080"),
+
+		SigningSecret: getEnv("SIGNING_SECRET", ""),
+
+		SiteURL:        getEnv("SITE_URL", ""),
+		SiteName:       getEnv("SITE_NAME", ""),
+		SPAArticlePath: getEnv("SPA_ARTICLE_PATH", "/article/:id"),
+
+		TrustedProxies: getEnv("TRUSTED_PROXIES", "127.0.0.1,::1,10.0.0.0/8,172.16.0.0/12,192.168.0.0/16"),
+
+		SpamBlocklist: getEnv("SPAM_BLOCKLIST", ""),
+
+		RateLimitComment:    getEnv("RATE_LIMIT_COMMENT", "5/1m"),
+		RateLimitView:       getEnv("RATE_LIMIT_VIEW", "30/1m"),
+		RateLimitRegister:   getEnv("RATE_LIMIT_REGISTER", "5/1h"),
+		RateLimitFetch:      getEnv("RATE_LIMIT_FETCH", "10/1m"),
+		RateLimitReaction:   getEnv("RATE_LIMIT_REACTION", "60/1m"),
+		RateLimitWebmention: getEnv("RATE_LIMIT_WEBMENTION", "10/1m"),
+		RateLimitReport:     getEnv("RATE_LIMIT_REPORT", "10/1h"),
+
+		ReportHideThreshold: getEnv("REPORT_HIDE_THRESHOLD", "3"),
+		ReportMinAccountAge: getEnv("REPORT_MIN_ACCOUNT_AGE", "72h"),
+
+		MailDriver:         getEnv("MAIL_DRIVER", "file"),
+		MailDir:            getEnv("MAIL_DIR", "./mail_outbox"),
+		MailFrom:           getEnv("MAIL_FROM", "noreply@localhost"),
+		SMTPAddr:           getEnv("SMTP_ADDR", ""),
+		SMTPUsername:       getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:       getEnv("SMTP_PASSWORD", ""),
+		MailInstantLimit:   getEnv("MAIL_INSTANT_LIMIT", "3"),
+		MailDigestInterval: getEnv("MAIL_DIGEST_INTERVAL", "1h"),
+
+		ViewRetentionDays: getEnv("VIEW_RETENTION_DAYS", "90"),
+
+		SearchIndexPath: getEnv("SEARCH_INDEX_PATH", "./data/search.bleve"),
 	}
 }
 
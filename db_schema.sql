@@ -62,13 +62,27 @@ CREATE TABLE `articles` (
   `category_id` varchar(191) DEFAULT NULL,
   `status` longtext,
   `views` bigint(20) DEFAULT NULL,
+  `unique_views` bigint(20) DEFAULT 0,
+  `locale` varchar(16) DEFAULT 'zh',
+  `translation_group_id` varchar(191) DEFAULT NULL,
   `author_id` varchar(191) DEFAULT NULL,
   `created_at` datetime(3) DEFAULT NULL,
   `updated_at` datetime(3) DEFAULT NULL,
   `published_at` datetime(3) DEFAULT NULL,
+  `word_count` bigint(20) DEFAULT NULL,
+  `reading_time` bigint(20) DEFAULT NULL,
+  `keywords` text,
+  `meta_title` longtext,
+  `meta_description` longtext,
+  `canonical_url` longtext,
+  `og_image` longtext,
   PRIMARY KEY (`id`),
   KEY `fk_articles_category` (`category_id`),
   KEY `fk_articles_author` (`author_id`),
+  KEY `idx_articles_locale` (`locale`),
+  KEY `idx_articles_translation_group_id` (`translation_group_id`),
+  FULLTEXT KEY `idx_articles_fulltext` (`title`,`excerpt`,`content`) /*!50100 WITH PARSER `ngram` */,
+  FULLTEXT KEY `idx_articles_title_fulltext` (`title`) /*!50100 WITH PARSER `ngram` */,
   CONSTRAINT `fk_articles_author` FOREIGN KEY (`author_id`) REFERENCES `users` (`id`),
   CONSTRAINT `fk_articles_category` FOREIGN KEY (`category_id`) REFERENCES `categories` (`id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -82,13 +96,34 @@ CREATE TABLE `comments` (
   `article_id` varchar(191) DEFAULT NULL,
   `author` longtext,
   `email` longtext,
+  `email_notify` tinyint(1) DEFAULT 0,
+  `user_id` varchar(191) DEFAULT NULL,
   `content` longtext,
-  `status` longtext,
+  `status` varchar(32) DEFAULT NULL,
+  `parent_id` varchar(191) DEFAULT NULL,
+  `root_id` varchar(191) DEFAULT NULL,
+  `depth` bigint(20) DEFAULT 0,
+  `is_author` tinyint(1) DEFAULT 0,
+  `ip` varchar(64) DEFAULT NULL,
+  `user_agent` longtext,
+  `spam_score` double DEFAULT NULL,
+  `spam_reasons` text,
+  `spam_trained` varchar(8) DEFAULT NULL,
+  `edited_at` datetime(3) DEFAULT NULL,
+  `type` varchar(16) DEFAULT 'comment',
+  `source_url` varchar(512) DEFAULT NULL,
+  `author_url` longtext,
   `created_at` datetime(3) DEFAULT NULL,
   `updated_at` datetime(3) DEFAULT NULL,
   PRIMARY KEY (`id`),
   KEY `fk_articles_comments` (`article_id`),
-  CONSTRAINT `fk_articles_comments` FOREIGN KEY (`article_id`) REFERENCES `articles` (`id`)
+  KEY `idx_comments_source_url` (`source_url`),
+  KEY `idx_comments_status` (`status`),
+  KEY `idx_comments_parent_id` (`parent_id`),
+  KEY `idx_comments_root_id` (`root_id`),
+  KEY `idx_comments_user_id` (`user_id`),
+  CONSTRAINT `fk_articles_comments` FOREIGN KEY (`article_id`) REFERENCES `articles` (`id`),
+  CONSTRAINT `fk_comments_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
 -- ----------------------------
@@ -100,12 +135,54 @@ CREATE TABLE `article_views` (
   `article_id` varchar(191) DEFAULT NULL,
   `ip` longtext,
   `user_agent` longtext,
+  `browser` varchar(32) DEFAULT NULL,
+  `os` varchar(32) DEFAULT NULL,
+  `device` varchar(16) DEFAULT NULL,
+  `referrer` varchar(191) DEFAULT NULL,
+  `utm_source` varchar(100) DEFAULT NULL,
+  `utm_medium` varchar(100) DEFAULT NULL,
+  `utm_campaign` varchar(100) DEFAULT NULL,
   `created_at` datetime(3) DEFAULT NULL,
   PRIMARY KEY (`id`),
   KEY `fk_articles_article_views` (`article_id`),
+  KEY `idx_article_views_referrer` (`referrer`),
+  KEY `idx_article_views_created_at` (`created_at`),
   CONSTRAINT `fk_articles_article_views` FOREIGN KEY (`article_id`) REFERENCES `articles` (`id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
+-- ----------------------------
+-- Table structure for article_view_rollups
+-- ----------------------------
+DROP TABLE IF EXISTS `article_view_rollups`;
+CREATE TABLE `article_view_rollups` (
+  `id` varchar(191) NOT NULL,
+  `article_id` varchar(191) DEFAULT NULL,
+  `date` varchar(10) DEFAULT NULL,
+  `views` bigint DEFAULT NULL,
+  `uniques` bigint DEFAULT NULL,
+  `top_referrer` varchar(191) DEFAULT NULL,
+  `top_ua_family` varchar(32) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_rollup_article_date` (`article_id`,`date`),
+  KEY `idx_article_view_rollups_date` (`date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ----------------------------
+-- Table structure for article_view_breakdowns
+-- ----------------------------
+DROP TABLE IF EXISTS `article_view_breakdowns`;
+CREATE TABLE `article_view_breakdowns` (
+  `id` varchar(191) NOT NULL,
+  `article_id` varchar(191) DEFAULT NULL,
+  `date` varchar(10) DEFAULT NULL,
+  `dimension` varchar(32) DEFAULT NULL,
+  `value` varchar(191) DEFAULT NULL,
+  `views` bigint DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_breakdown_article_date` (`article_id`,`date`),
+  KEY `idx_breakdown_date_dimension` (`date`,`dimension`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
 -- ----------------------------
 -- Table structure for article_tags
 -- ----------------------------
@@ -151,6 +228,110 @@ CREATE TABLE `donation_qr_codes` (
   PRIMARY KEY (`id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
+-- ----------------------------
+-- Table structure for article_templates
+-- ----------------------------
+DROP TABLE IF EXISTS `article_templates`;
+CREATE TABLE `article_templates` (
+  `id` varchar(191) NOT NULL,
+  `name` longtext,
+  `description` longtext,
+  `title_pattern` longtext,
+  `content_skeleton` longtext,
+  `default_category_id` varchar(191) DEFAULT NULL,
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `fk_article_templates_default_category` (`default_category_id`),
+  CONSTRAINT `fk_article_templates_default_category` FOREIGN KEY (`default_category_id`) REFERENCES `categories` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ----------------------------
+-- Table structure for article_template_tags
+-- ----------------------------
+DROP TABLE IF EXISTS `article_template_tags`;
+CREATE TABLE `article_template_tags` (
+  `article_template_id` varchar(191) NOT NULL,
+  `tag_id` varchar(191) NOT NULL,
+  PRIMARY KEY (`article_template_id`,`tag_id`),
+  KEY `fk_article_template_tags_tag` (`tag_id`),
+  CONSTRAINT `fk_article_template_tags_article_template` FOREIGN KEY (`article_template_id`) REFERENCES `article_templates` (`id`),
+  CONSTRAINT `fk_article_template_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ----------------------------
+-- Table structure for reactions
+-- ----------------------------
+DROP TABLE IF EXISTS `reactions`;
+CREATE TABLE `reactions` (
+  `id` varchar(191) NOT NULL,
+  `target_type` varchar(16) DEFAULT NULL,
+  `target_id` varchar(191) DEFAULT NULL,
+  `kind` varchar(32) DEFAULT NULL,
+  `actor` varchar(191) DEFAULT NULL,
+  `created_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_reaction_actor` (`target_type`,`target_id`,`kind`,`actor`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ----------------------------
+-- Table structure for notifications
+-- ----------------------------
+DROP TABLE IF EXISTS `notifications`;
+CREATE TABLE `notifications` (
+  `id` varchar(191) NOT NULL,
+  `user_id` varchar(191) DEFAULT NULL,
+  `type` varchar(32) DEFAULT NULL,
+  `article_id` varchar(191) DEFAULT NULL,
+  `comment_id` varchar(191) DEFAULT NULL,
+  `actor_id` varchar(191) DEFAULT NULL,
+  `actor_name` longtext,
+  `title` longtext,
+  `message` text,
+  `read_at` datetime(3) DEFAULT NULL,
+  `created_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_notifications_user_read` (`user_id`,`read_at`),
+  KEY `idx_notifications_article_id` (`article_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ----------------------------
+-- Table structure for email_unsubscribes
+-- ----------------------------
+DROP TABLE IF EXISTS `email_unsubscribes`;
+CREATE TABLE `email_unsubscribes` (
+  `id` varchar(191) NOT NULL,
+  `email` varchar(191) DEFAULT NULL,
+  `created_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_email_unsubscribes_email` (`email`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ----------------------------
+-- Table structure for reports
+-- ----------------------------
+DROP TABLE IF EXISTS `reports`;
+CREATE TABLE `reports` (
+  `id` varchar(191) NOT NULL,
+  `target_type` varchar(16) DEFAULT NULL,
+  `target_id` varchar(191) DEFAULT NULL,
+  `reporter` varchar(191) DEFAULT NULL,
+  `reporter_id` varchar(191) DEFAULT NULL,
+  `reason` varchar(32) DEFAULT NULL,
+  `detail` text,
+  `status` varchar(16) DEFAULT NULL,
+  `hidden_from` varchar(32) DEFAULT NULL,
+  `resolved_by` varchar(191) DEFAULT NULL,
+  `resolved_at` datetime(3) DEFAULT NULL,
+  `resolution` text,
+  `created_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_report_reporter` (`target_type`,`target_id`,`reporter`),
+  KEY `idx_report_target` (`target_type`,`target_id`),
+  KEY `idx_reports_reporter_id` (`reporter_id`),
+  KEY `idx_reports_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
 -- ----------------------------
 -- Insert Default Data
 -- ----------------------------
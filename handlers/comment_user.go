@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+	"github.com/ranp9275-sketch/blog-backend-golang/spam"
+)
+
+const (
+	commentEditWindow       = 15 * time.Minute // 发表后允许作者编辑、删除评论的时间
+	trustedCommentThreshold = 3                // 已通过审核的评论数达到该值的用户视为可信用户
+)
+
+// isTrustedCommenter 管理员、文章作者以及有足够多通过审核评论的用户，评论免审核
+func (h *Handlers) isTrustedCommenter(user *models.User, article *models.Article) bool {
+	if user.Role == "admin" {
+		return true
+	}
+	if article.AuthorID != nil && *article.AuthorID == user.ID {
+		return true
+	}
+	count, err := h.repo.CountApprovedCommentsByUser(user.ID)
+	return err == nil && count >= trustedCommentThreshold
+}
+
+// applySpamVerdict 运行反垃圾检测并根据结果设置评论状态
+func (h *Handlers) applySpamVerdict(ctx context.Context, comment *models.Comment, input *spam.Input) {
+	result := h.spamFilter.Check(ctx, input)
+
+	comment.SpamScore = result.Score
+	comment.SpamReasons = strings.Join(result.Reasons(), "\n")
+	switch result.Verdict {
+	case spam.VerdictHam:
+		comment.Status = CommentStatusApproved
+	case spam.VerdictSpam:
+		comment.Status = CommentStatusSpam
+	default:
+		comment.Status = CommentStatusPending
+	}
+}
+
+// getOwnComment 获取当前用户在编辑时限内的评论，失败时已写入响应
+func (h *Handlers) getOwnComment(c *gin.Context) (*models.Comment, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return nil, false
+	}
+
+	comment, err := h.repo.GetCommentByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return nil, false
+	}
+
+	if comment.UserID == nil || *comment.UserID != userID.(string) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only modify your own comments"})
+		return nil, false
+	}
+	if comment.Status != CommentStatusApproved && comment.Status != CommentStatusPending {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Comment can no longer be modified"})
+		return nil, false
+	}
+	if time.Since(comment.CreatedAt) > commentEditWindow {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Edit window has expired"})
+		return nil, false
+	}
+
+	return comment, true
+}
+
+// UpdateUserComment 用户在编辑时限内修改自己的评论；非可信用户的修改重新经过反垃圾检测
+func (h *Handlers) UpdateUserComment(c *gin.Context) {
+	comment, ok := h.getOwnComment(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Content   string `json:"content" binding:"required"`
+		FormToken string `json:"form_token"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	article, err := h.repo.GetArticleByIDWithoutStatus(comment.ArticleID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+		return
+	}
+	user, err := h.repo.GetUserByID(*comment.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	now := time.Now()
+	comment.Content = req.Content
+	comment.EditedAt = &now
+	comment.UpdatedAt = now
+
+	if !h.isTrustedCommenter(user, article) {
+		input := spamInput(comment)
+		input.FormToken = req.FormToken
+		h.applySpamVerdict(c.Request.Context(), comment, input)
+	}
+
+	if err := h.repo.UpdateComment(comment.ID, map[string]interface{}{
+		"content":      comment.Content,
+		"status":       comment.Status,
+		"spam_score":   comment.SpamScore,
+		"spam_reasons": comment.SpamReasons,
+		"edited_at":    now,
+		"updated_at":   now,
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 不向提交者暴露反垃圾判定结果
+	if comment.Status == CommentStatusSpam {
+		comment.Status = CommentStatusPending
+	}
+	comment.User = &models.User{ID: user.ID, Name: user.Name, Avatar: user.Avatar}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// DeleteUserComment 用户在编辑时限内删除自己的评论；已有回复的评论不能删除
+func (h *Handlers) DeleteUserComment(c *gin.Context) {
+	comment, ok := h.getOwnComment(c)
+	if !ok {
+		return
+	}
+
+	replies, err := h.repo.CountCommentReplies(comment.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if replies > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Comment already has replies"})
+		return
+	}
+
+	if err := h.repo.DeleteComment(comment.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted"})
+}
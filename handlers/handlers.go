@@ -12,17 +12,34 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/ranp9275-sketch/blog-backend-golang/mail"
 	"github.com/ranp9275-sketch/blog-backend-golang/models"
 	"github.com/ranp9275-sketch/blog-backend-golang/repository"
+	"github.com/ranp9275-sketch/blog-backend-golang/search"
+	"github.com/ranp9275-sketch/blog-backend-golang/spam"
+	"github.com/ranp9275-sketch/blog-backend-golang/useragent"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type Handlers struct {
-	repo *repository.Repository
+	repo       *repository.Repository
+	spamFilter *spam.Filter
+	mailer     *mail.Notifier
+	index      *search.Index // 为 nil 时搜索只使用数据库
+	suggester  *search.Suggester
+	settings   Settings
 }
 
-func NewHandlers(repo *repository.Repository) *Handlers {
-	return &Handlers{repo: repo}
+// Settings 处理器用到的配置，由 main 从 config.Config 解析
+type Settings struct {
+	SiteURL        string                      // 站点对外访问地址，用于生成绝对链接
+	SiteName       string                      // 站点名称（og:site_name）
+	SPAArticlePath string                      // 前端文章路由，支持 :id 和 :slug 占位符
+	ReportHide     repository.ReportHidePolicy // 举报自动隐藏规则
+}
+
+func NewHandlers(repo *repository.Repository, spamFilter *spam.Filter, mailer *mail.Notifier, index *search.Index, suggester *search.Suggester, settings Settings) *Handlers {
+	return &Handlers{repo: repo, spamFilter: spamFilter, mailer: mailer, index: index, suggester: suggester, settings: settings}
 }
 
 // ==================== Article Handlers ====================
@@ -30,8 +47,9 @@ func NewHandlers(repo *repository.Repository) *Handlers {
 func (h *Handlers) GetArticles(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	locale := requestLocale(c)
 
-	articles, total, err := h.repo.GetArticles(page, pageSize)
+	articles, total, err := h.repo.GetArticles(page, pageSize, locale)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -45,6 +63,22 @@ func (h *Handlers) GetArticles(c *gin.Context) {
 	})
 }
 
+// GetTrendingArticles 按近期浏览、评论和收藏的时间衰减热度排序的已发布文章
+func (h *Handlers) GetTrendingArticles(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	articles, err := h.repo.GetTrendingArticles(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": articles})
+}
+
 func (h *Handlers) GetArticleByID(c *gin.Context) {
 	id := c.Param("id")
 
@@ -53,6 +87,7 @@ func (h *Handlers) GetArticleByID(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
 		return
 	}
+	h.attachArticleLikes(article, visitorID(c))
 
 	c.JSON(http.StatusOK, article)
 }
@@ -96,11 +131,15 @@ func (h *Handlers) GetArticlesByTag(c *gin.Context) {
 }
 
 func (h *Handlers) CreateArticle(c *gin.Context) {
-	var article models.Article
-	if err := c.ShouldBindJSON(&article); err != nil {
+	var req struct {
+		models.Article
+		TranslationOf string `json:"translation_of"` // 可选：作为该文章的译文
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	article := req.Article
 
 	// 处理可能为空的指针字段
 	if article.CategoryID != nil && *article.CategoryID == "" {
@@ -117,14 +156,35 @@ func (h *Handlers) CreateArticle(c *gin.Context) {
 		article.AuthorID = &authorID
 	}
 
+	article.Locale = normalizeLocale(article.Locale)
+	if article.Locale == "" {
+		article.Locale = defaultLocale
+	}
+	article.TranslationGroupID = nil
+
+	if req.TranslationOf != "" {
+		if _, err := h.repo.GetArticleByIDWithoutStatus(req.TranslationOf); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Translation source not found"})
+			return
+		}
+	}
+
+	applyTextStats(&article)
+
 	article.ID = uuid.New().String()
 	article.CreatedAt = time.Now()
 	article.UpdatedAt = time.Now()
 
-	if err := h.repo.CreateArticle(&article); err != nil {
+	if err := h.repo.CreateArticleWithTags(&article, nil, req.TranslationOf); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.indexArticles(article.ID)
+
+	h.attachSuggestedTags(&article)
+	if article.Status == "published" {
+		h.sendWebmentions(article.ID)
+	}
 
 	c.JSON(http.StatusCreated, article)
 }
@@ -150,12 +210,51 @@ func (h *Handlers) UpdateArticle(c *gin.Context) {
 		}
 	}
 
+	// 语言与译文分组
+	if val, ok := updates["locale"]; ok {
+		s, _ := val.(string)
+		locale := normalizeLocale(s)
+		if locale == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported locale"})
+			return
+		}
+		updates["locale"] = locale
+	}
+	translationOf, _ := updates["translation_of"].(string)
+	delete(updates, "translation_of")
+	if translationOf != "" {
+		if translationOf == id {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Article cannot be a translation of itself"})
+			return
+		}
+		if _, err := h.repo.GetArticleByIDWithoutStatus(translationOf); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Translation source not found"})
+			return
+		}
+	}
+
+	// 正文变更时重新生成摘要、字数、阅读时长和关键词
+	_, hasContent := updates["content"]
+	_, hasTitle := updates["title"]
+	if hasContent || hasTitle {
+		existing, err := h.repo.GetArticleByIDWithoutStatus(id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+			return
+		}
+		applyTextStatsToUpdates(existing, updates)
+	}
+
 	updates["updated_at"] = time.Now()
 
-	if err := h.repo.UpdateArticle(id, updates); err != nil {
+	if err := h.repo.UpdateArticleWithTags(id, updates, nil, translationOf); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.indexArticles(id)
+	if updates["status"] == "published" {
+		h.sendWebmentions(id)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Article updated"})
 }
@@ -167,6 +266,7 @@ func (h *Handlers) DeleteArticle(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.indexArticles(id)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Article deleted"})
 }
@@ -183,6 +283,8 @@ func (h *Handlers) PublishArticle(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.indexArticles(id)
+	h.sendWebmentions(id)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Article published"})
 }
@@ -311,37 +413,152 @@ func (h *Handlers) DeleteTag(c *gin.Context) {
 
 // ==================== Comment Handlers ====================
 
+// maxCommentDepth 评论最大嵌套层级（顶层为 0），超出时回复挂到同一层
+const maxCommentDepth = 3
+
 func (h *Handlers) GetComments(c *gin.Context) {
 	articleID := c.Param("id")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
 
-	comments, err := h.repo.GetCommentsByArticleID(articleID)
+	comments, total, err := h.repo.GetCommentThreads(articleID, page, pageSize)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.attachCommentReactions(comments, visitorID(c))
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     comments,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// GetCommentToken 下发评论表单的停留时间令牌，前端在页面加载时获取并随评论提交
+func (h *Handlers) GetCommentToken(c *gin.Context) {
+	articleID := c.Param("id")
 
-	c.JSON(http.StatusOK, comments)
+	c.JSON(http.StatusOK, gin.H{
+		"token":       h.spamFilter.IssueToken(articleID),
+		"min_seconds": h.spamFilter.MinSubmitSeconds(),
+	})
 }
 
 func (h *Handlers) CreateComment(c *gin.Context) {
 	articleID := c.Param("id")
-	var comment models.Comment
+	var req struct {
+		models.Comment
+		Email     string `json:"email" binding:"omitempty,email"` // 匿名评论者的邮箱，不在公开接口中返回
+		Notify    bool   `json:"notify"`                          // 匿名评论者同意接收审核结果和回复邮件
+		Website   string `json:"website"`                         // 蜜罐字段，前端隐藏，正常用户不会填写
+		FormToken string `json:"form_token"`                      // GetCommentToken 下发的令牌
+	}
 
-	if err := c.ShouldBindJSON(&comment); err != nil {
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	comment := req.Comment
+	comment.Email = req.Email
+	comment.EmailNotify = req.Notify && req.Email != ""
+
+	article, err := h.repo.GetArticleByID(articleID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+		return
+	}
+
+	// 回复：校验父评论并计算层级，超过最大层级时挂到父评论的上一级
+	comment.RootID = nil
+	comment.Depth = 0
+	if comment.ParentID != nil && *comment.ParentID == "" {
+		comment.ParentID = nil
+	}
+	if comment.ParentID != nil {
+		parent, err := h.repo.GetCommentByID(*comment.ParentID)
+		if err != nil || parent.ArticleID != articleID || parent.Status != CommentStatusApproved {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent comment not found"})
+			return
+		}
+
+		rootID := parent.ID
+		if parent.RootID != nil {
+			rootID = *parent.RootID
+		}
+		comment.RootID = &rootID
+		comment.Depth = parent.Depth + 1
+		if comment.Depth > maxCommentDepth {
+			comment.ParentID = parent.ParentID
+			comment.Depth = parent.Depth
+		}
+	}
+
+	// 登录用户的评论关联账号并使用账号昵称，防止冒充他人
+	comment.UserID = nil
+	comment.User = nil
+	comment.IsAuthor = false
+	var commenter *models.User
+	if userID, exists := c.Get("userID"); exists {
+		user, err := h.repo.GetUserByID(userID.(string))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+		commenter = user
+		comment.UserID = &user.ID
+		comment.Author = user.Name
+		comment.Email = ""
+		comment.EmailNotify = false
+		// 文章作者登录后发表的评论做特殊标记
+		comment.IsAuthor = article.AuthorID != nil && user.ID == *article.AuthorID
+	}
 
 	comment.ID = uuid.New().String()
 	comment.ArticleID = articleID
-	comment.Status = "pending"
+	comment.Type = CommentTypeComment
+	comment.SourceURL = ""
+	comment.AuthorURL = ""
+	comment.IP = c.ClientIP()
+	comment.UserAgent = c.Request.UserAgent()
+	comment.SpamTrained = ""
+	comment.EditedAt = nil
 	comment.CreatedAt = time.Now()
 	comment.UpdatedAt = time.Now()
 
+	if commenter != nil && h.isTrustedCommenter(commenter, article) {
+		// 可信用户的评论免审核
+		comment.Status = CommentStatusApproved
+		comment.SpamScore = 0
+		comment.SpamReasons = ""
+	} else {
+		// 反垃圾检测：高置信度正常评论自动通过，垃圾评论直接标记
+		input := spamInput(&comment)
+		input.Honeypot = req.Website
+		input.FormToken = req.FormToken
+		h.applySpamVerdict(c.Request.Context(), &comment, input)
+	}
+
 	if err := h.repo.CreateComment(&comment); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	switch comment.Status {
+	case CommentStatusApproved:
+		h.notifyCommentApproved(&comment)
+		h.repo.TrackTrending(comment.ArticleID, repository.TrendingWeightComment)
+	case CommentStatusPending:
+		h.emailCommentPending(&comment, article)
+	}
+
+	// 不向提交者暴露反垃圾判定结果
+	if comment.Status == CommentStatusSpam {
+		comment.Status = CommentStatusPending
+	}
+	if commenter != nil {
+		comment.User = &models.User{ID: commenter.ID, Name: commenter.Name, Avatar: commenter.Avatar}
+	}
 
 	c.JSON(http.StatusCreated, comment)
 }
@@ -361,74 +578,236 @@ func (h *Handlers) DeleteComment(c *gin.Context) {
 
 func (h *Handlers) RecordView(c *gin.Context) {
 	articleID := c.Param("id")
-	ip := c.ClientIP()
 	userAgent := c.Request.UserAgent()
 
+	// 爬虫和脚本请求不计入浏览量
+	if isBotUserAgent(userAgent) {
+		c.JSON(http.StatusOK, gin.H{"message": "View ignored", "counted": false})
+		return
+	}
+
+	// 来源由前端上报 document.referrer，请求本身的 Referer 头是前端页面，不能代表来源；
+	// UTM 参数来自文章页地址，同样由前端上报
+	var req struct {
+		Referrer    string `json:"referrer"`
+		UTMSource   string `json:"utm_source"`
+		UTMMedium   string `json:"utm_medium"`
+		UTMCampaign string `json:"utm_campaign"`
+	}
+	if c.Request.ContentLength > 0 {
+		c.ShouldBindJSON(&req)
+	}
+
+	// 只记录已发布的文章，避免任意 ID 在 Redis 中留下永久的计数键
+	published, err := h.repo.ArticlePublished(articleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !published {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+		return
+	}
+
+	agent := useragent.Parse(userAgent)
 	view := &models.ArticleView{
-		ArticleID: articleID,
-		IP:        ip,
-		UserAgent: userAgent,
-		CreatedAt: time.Now(),
+		ArticleID:   articleID,
+		IP:          anonymizeIP(c.ClientIP()),
+		UserAgent:   userAgent,
+		Browser:     agent.Browser,
+		OS:          agent.OS,
+		Device:      agent.Device,
+		Referrer:    h.referrerHost(req.Referrer),
+		UTMSource:   utmValue(req.UTMSource),
+		UTMMedium:   utmValue(req.UTMMedium),
+		UTMCampaign: utmValue(req.UTMCampaign),
+		CreatedAt:   time.Now(),
 	}
 
-	if err := h.repo.RecordView(view); err != nil {
+	// 浏览量与浏览记录先缓冲在 Redis，由后台任务批量写入数据库；同一访客在去重窗口内重复浏览只记一次
+	counted, err := h.repo.RecordView(view, visitorID(c))
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 增加文章浏览次数
-	h.repo.IncrementArticleViews(articleID)
+	c.JSON(http.StatusOK, gin.H{"message": "View recorded", "counted": counted})
+}
 
-	c.JSON(http.StatusOK, gin.H{"message": "View recorded"})
+// statsBreakdowns 文章统计接口支持的拆分方式及其包含的维度
+var statsBreakdowns = map[string][]string{
+	"referrer": {repository.BreakdownReferrer, repository.BreakdownUTMSource, repository.BreakdownUTMMedium, repository.BreakdownUTMCampaign},
+	"device":   {repository.BreakdownDevice, repository.BreakdownOS, repository.BreakdownUAFamily},
 }
 
 func (h *Handlers) GetArticleStats(c *gin.Context) {
 	articleID := c.Param("id")
 
-	views, comments, err := h.repo.GetArticleStats(articleID)
+	dimensions, ok := statsBreakdowns[c.Query("breakdown")]
+	if !ok && c.Query("breakdown") != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid breakdown, expected referrer or device"})
+		return
+	}
+
+	views, uniqueViews, comments, err := h.repo.GetArticleStats(articleID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"views": views, "comments": comments})
+	// 点赞数与评论表情回应总数
+	var likes int64
+	reactions := map[string]int64{}
+	if counts, err := h.repo.CountReactions(repository.ReactionTargetArticle, []string{articleID}, []string{repository.ReactionLike}); err == nil {
+		likes = counts[articleID][repository.ReactionLike]
+	}
+	if commentIDs, err := h.repo.GetCommentIDsByArticle(articleID); err == nil {
+		counts, _ := h.repo.CountReactions(repository.ReactionTargetComment, commentIDs, commentReactionKinds)
+		for _, byKind := range counts {
+			for kind, n := range byKind {
+				reactions[kind] += n
+			}
+		}
+	}
+
+	stats := gin.H{
+		"views":        views,
+		"unique_views": uniqueViews,
+		"comments":     comments,
+		"likes":        likes,
+		"reactions":    reactions,
+	}
+
+	// 按来源或设备拆分时间段内的浏览量，默认最近 30 天
+	if len(dimensions) > 0 {
+		from, to, err := parseStatsRange(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+		if limit <= 0 || limit > 50 {
+			limit = 10
+		}
+
+		breakdown := gin.H{}
+		for _, dimension := range dimensions {
+			values, err := h.repo.GetViewBreakdown(articleID, dimension, from, to.AddDate(0, 0, 1), limit)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			breakdown[dimension] = values
+		}
+		stats["from"] = from.Format("2006-01-02")
+		stats["to"] = to.Format("2006-01-02")
+		stats["breakdown"] = breakdown
+	}
+
+	c.JSON(http.StatusOK, stats)
 }
 
 // ==================== Stats Handlers ====================
 
-// func (h *Handlers) GetStats(c *gin.Context) {
-// 	stats, err := h.repo.GetStats()
-// 	if err != nil {
-// 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-// 		return
-// 	}
+const maxStatsRangeDays = 366
+
+// parseStatsRange 解析 from / to（YYYY-MM-DD，包含两端），默认最近 30 天
+func parseStatsRange(c *gin.Context) (from, to time.Time, err error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	to = today
+	if value := c.Query("to"); value != "" {
+		if to, err = time.ParseInLocation("2006-01-02", value, time.Local); err != nil {
+			return from, to, fmt.Errorf("invalid to date, expected YYYY-MM-DD")
+		}
+	}
+	from = to.AddDate(0, 0, -29)
+	if value := c.Query("from"); value != "" {
+		if from, err = time.ParseInLocation("2006-01-02", value, time.Local); err != nil {
+			return from, to, fmt.Errorf("invalid from date, expected YYYY-MM-DD")
+		}
+	}
+
+	if from.After(to) {
+		return from, to, fmt.Errorf("from must not be after to")
+	}
+	if to.Sub(from) >= maxStatsRangeDays*24*time.Hour {
+		return from, to, fmt.Errorf("date range must not exceed %d days", maxStatsRangeDays)
+	}
+	return from, to, nil
+}
 
-// 	c.JSON(http.StatusOK, stats)
-// }
+// GetStats 管理员数据看板：站点总量、按天的浏览 / 新评论 / 新用户趋势、时间段内的热门文章和来源站点
+func (h *Handlers) GetStats(c *gin.Context) {
+	from, to, err := parseStatsRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+	end := to.AddDate(0, 0, 1)
 
-// ==================== Search Handlers ====================
+	totals, err := h.repo.GetDashboardTotals()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	series, err := h.repo.GetDailyStats(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	topArticles, err := h.repo.GetTopArticlesByViews(from, end, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	topReferrers, err := h.repo.GetTopReferrers(from, end, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-func (h *Handlers) SearchArticles(c *gin.Context) {
-	query := c.Query("q")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	c.JSON(http.StatusOK, gin.H{
+		"from":          from.Format("2006-01-02"),
+		"to":            to.Format("2006-01-02"),
+		"totals":        totals,
+		"series":        series,
+		"top_articles":  topArticles,
+		"top_referrers": topReferrers,
+	})
+}
 
-	if query == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query is required"})
+// GetUserStats 当前用户自己文章的浏览、独立访客、评论和收藏统计，含各文章数据和按天趋势
+func (h *Handlers) GetUserStats(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
 		return
 	}
 
-	articles, total, err := h.repo.SearchArticles(query, page, pageSize)
+	from, to, err := parseStatsRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stats, err := h.repo.GetAuthorStats(userID.(string), from, to)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data":     articles,
-		"total":    total,
-		"page":     page,
-		"pageSize": pageSize,
+		"from":     from.Format("2006-01-02"),
+		"to":       to.Format("2006-01-02"),
+		"totals":   stats.Totals,
+		"articles": stats.Articles,
+		"series":   stats.Series,
 	})
 }
 
@@ -491,10 +870,15 @@ func (h *Handlers) AddFavorite(c *gin.Context) {
 		CreatedAt: time.Now(),
 	}
 
-	if err := h.repo.AddFavorite(favorite); err != nil {
+	added, err := h.repo.AddFavorite(favorite)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	// 重复收藏不再通知作者
+	if added {
+		h.notifyArticleFavorited(req.ArticleID, favorite.UserID)
+	}
 
 	c.JSON(http.StatusCreated, gin.H{"message": "Favorite added"})
 }
@@ -527,13 +911,17 @@ func (h *Handlers) CreateUserArticle(c *gin.Context) {
 	}
 
 	var req struct {
-		Title      string      `json:"title" binding:"required"`
-		Content    string      `json:"content" binding:"required"`
-		Excerpt    string      `json:"excerpt"`
-		CoverImage string      `json:"cover_image"`
-		CategoryID string      `json:"category_id"`
-		TagIDs     []string    `json:"tag_ids"`
-		Submit     interface{} `json:"submit"` // 兼容 bool 或其他类型
+		Title         string   `json:"title"`
+		Content       string   `json:"content"`
+		Excerpt       string   `json:"excerpt"`
+		CoverImage    string   `json:"cover_image"`
+		CategoryID    string   `json:"category_id"`
+		TagIDs        []string `json:"tag_ids"`
+		TemplateID    string   `json:"template_id"`    // 可选：使用模板预填充
+		Locale        string   `json:"locale"`         // 可选：zh, en
+		TranslationOf string   `json:"translation_of"` // 可选：作为自己某篇文章的译文
+		SEOFields
+		Submit interface{} `json:"submit"` // 兼容 bool 或其他类型
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -541,6 +929,54 @@ func (h *Handlers) CreateUserArticle(c *gin.Context) {
 		return
 	}
 
+	// 使用模板预填充标题、正文、分类和标签（请求中显式提供的字段优先）
+	if req.TemplateID != "" {
+		template, err := h.repo.GetArticleTemplateByID(req.TemplateID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+			return
+		}
+
+		authorName := ""
+		if user, err := h.repo.GetUserByID(userID.(string)); err == nil {
+			authorName = user.Name
+		}
+		vars := templateVars(authorName, time.Now())
+
+		if req.Title == "" {
+			req.Title = renderTemplate(template.TitlePattern, vars)
+		}
+		if req.Content == "" {
+			req.Content = renderTemplate(template.ContentSkeleton, vars)
+		}
+		if req.CategoryID == "" && template.DefaultCategoryID != nil {
+			req.CategoryID = *template.DefaultCategoryID
+		}
+		if req.TagIDs == nil {
+			for _, tag := range template.DefaultTags {
+				req.TagIDs = append(req.TagIDs, tag.ID)
+			}
+		}
+	}
+
+	if req.Title == "" || req.Content == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Title and content are required"})
+		return
+	}
+
+	locale := defaultLocale
+	if req.Locale != "" {
+		if locale = normalizeLocale(req.Locale); locale == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported locale"})
+			return
+		}
+	}
+
+	if req.TranslationOf != "" && !h.ownsArticle(userID.(string), req.TranslationOf) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only link translations of your own articles"})
+		return
+	}
+
 	status := "draft"
 	var publishedAt *time.Time
 	if submit, ok := req.Submit.(bool); ok && submit {
@@ -557,23 +993,35 @@ func (h *Handlers) CreateUserArticle(c *gin.Context) {
 	}
 
 	article := &models.Article{
-		ID:          uuid.New().String(),
-		Title:       req.Title,
-		Content:     req.Content,
-		Excerpt:     req.Excerpt,
-		CoverImage:  req.CoverImage,
-		CategoryID:  categoryIDPtr,
-		AuthorID:    &authorID,
-		Status:      status,
-		PublishedAt: publishedAt,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-	}
-
-	if err := h.repo.CreateArticleWithTags(article, req.TagIDs); err != nil {
+		ID:              uuid.New().String(),
+		Title:           req.Title,
+		Content:         req.Content,
+		Excerpt:         req.Excerpt,
+		CoverImage:      req.CoverImage,
+		CategoryID:      categoryIDPtr,
+		AuthorID:        &authorID,
+		Locale:          locale,
+		Status:          status,
+		PublishedAt:     publishedAt,
+		MetaTitle:       req.MetaTitle,
+		MetaDescription: req.MetaDescription,
+		CanonicalURL:    req.CanonicalURL,
+		OGImage:         req.OGImage,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+	applyTextStats(article)
+
+	if err := h.repo.CreateArticleWithTags(article, req.TagIDs, req.TranslationOf); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.indexArticles(article.ID)
+
+	h.attachSuggestedTags(article)
+	if status == "published" {
+		h.sendWebmentions(article.ID)
+	}
 
 	c.JSON(http.StatusCreated, article)
 }
@@ -630,13 +1078,16 @@ func (h *Handlers) UpdateUserArticle(c *gin.Context) {
 	}
 
 	var req struct {
-		Title      string      `json:"title"`
-		Content    string      `json:"content"`
-		Excerpt    string      `json:"excerpt"`
-		CoverImage string      `json:"cover_image"`
-		CategoryID string      `json:"category_id"`
-		TagIDs     []string    `json:"tag_ids"`
-		Submit     interface{} `json:"submit"` // 兼容 bool 或其他类型
+		Title         string   `json:"title"`
+		Content       string   `json:"content"`
+		Excerpt       string   `json:"excerpt"`
+		CoverImage    string   `json:"cover_image"`
+		CategoryID    string   `json:"category_id"`
+		TagIDs        []string `json:"tag_ids"`
+		Locale        string   `json:"locale"`
+		TranslationOf string   `json:"translation_of"`
+		SEOFields
+		Submit interface{} `json:"submit"` // 兼容 bool 或其他类型
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -644,6 +1095,15 @@ func (h *Handlers) UpdateUserArticle(c *gin.Context) {
 		return
 	}
 
+	if req.TranslationOf == articleID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Article cannot be a translation of itself"})
+		return
+	}
+	if req.TranslationOf != "" && !h.ownsArticle(userID.(string), req.TranslationOf) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only link translations of your own articles"})
+		return
+	}
+
 	updates := map[string]interface{}{
 		"updated_at": time.Now(),
 	}
@@ -663,6 +1123,16 @@ func (h *Handlers) UpdateUserArticle(c *gin.Context) {
 	if req.CategoryID != "" {
 		updates["category_id"] = req.CategoryID
 	}
+	if req.Locale != "" {
+		locale := normalizeLocale(req.Locale)
+		if locale == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported locale"})
+			return
+		}
+		updates["locale"] = locale
+	}
+	req.SEOFields.applyTo(updates)
+	applyTextStatsToUpdates(article, updates)
 
 	// 处理 submit 字段
 	if submit, ok := req.Submit.(bool); ok && submit {
@@ -671,10 +1141,14 @@ func (h *Handlers) UpdateUserArticle(c *gin.Context) {
 		updates["published_at"] = now
 	}
 
-	if err := h.repo.UpdateArticleWithTags(articleID, updates, req.TagIDs); err != nil {
+	if err := h.repo.UpdateArticleWithTags(articleID, updates, req.TagIDs, req.TranslationOf); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.indexArticles(articleID)
+	if updates["status"] == "published" {
+		h.sendWebmentions(articleID)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Article updated"})
 }
@@ -705,6 +1179,7 @@ func (h *Handlers) DeleteUserArticle(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.indexArticles(articleID)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Article deleted"})
 }
@@ -759,6 +1234,12 @@ func (h *Handlers) RejectArticle(c *gin.Context) {
 	}
 	c.ShouldBindJSON(&req)
 
+	article, err := h.repo.GetArticleByIDWithoutStatus(articleID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+		return
+	}
+
 	if err := h.repo.UpdateArticle(articleID, map[string]interface{}{
 		"status":     "rejected",
 		"updated_at": time.Now(),
@@ -766,10 +1247,70 @@ func (h *Handlers) RejectArticle(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.indexArticles(articleID)
+	h.notifyArticleRejected(article, req.Reason)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Article rejected"})
 }
 
+// BulkArticles 批量操作文章：publish, unpublish, delete, move_category, add_tags, remove_tags
+func (h *Handlers) BulkArticles(c *gin.Context) {
+	var req struct {
+		IDs        []string `json:"ids" binding:"required,min=1,max=500"`
+		Action     string   `json:"action" binding:"required"`
+		CategoryID string   `json:"category_id"`
+		TagIDs     []string `json:"tag_ids"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	params := repository.BulkArticleParams{Action: req.Action}
+	switch req.Action {
+	case repository.BulkActionPublish, repository.BulkActionUnpublish, repository.BulkActionDelete:
+	case repository.BulkActionMoveCategory:
+		// category_id 为空表示移出分类
+		if req.CategoryID != "" {
+			params.CategoryID = &req.CategoryID
+		}
+	case repository.BulkActionAddTags, repository.BulkActionRemoveTags:
+		if len(req.TagIDs) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tag_ids is required for this action"})
+			return
+		}
+		params.TagIDs = req.TagIDs
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action"})
+		return
+	}
+
+	results, err := h.repo.BulkUpdateArticles(req.IDs, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	succeeded := 0
+	for _, result := range results {
+		if result.Success {
+			succeeded++
+			h.indexArticles(result.ID)
+			if req.Action == repository.BulkActionPublish {
+				h.sendWebmentions(result.ID)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"action":    req.Action,
+		"results":   results,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+	})
+}
+
 // ==================== Profile Handlers ====================
 
 // UpdateProfile 更新个人信息
@@ -1151,12 +1692,17 @@ func (h *Handlers) UploadArticle(c *gin.Context) {
 		UpdatedAt:   now,
 		PublishedAt: &now,
 	}
+	applyTextStats(article)
 
 	// 保存到数据库
 	if err := h.repo.CreateArticle(article); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.indexArticles(article.ID)
+	h.sendWebmentions(article.ID)
+
+	h.attachSuggestedTags(article)
 
 	c.JSON(http.StatusCreated, article)
 }
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ranp9275-sketch/blog-backend-golang/mail"
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+)
+
+// sendMail 异步发送邮件通知，避免 SMTP 延迟拖慢请求
+func (h *Handlers) sendMail(notice mail.Notice) {
+	if notice.To == "" {
+		return
+	}
+	go func() {
+		if err := h.mailer.Notify(context.Background(), notice); err != nil {
+			log.Printf("[Mail] Failed to send %s to %s: %v", notice.Kind, notice.To, err)
+		}
+	}()
+}
+
+// commenterContact 评论者的收件邮箱和称呼：登录用户取账号邮箱；匿名评论取填写的邮箱，
+// 邮箱未经验证，只有评论者勾选接收邮件时才发送，避免借评论向任意地址发信
+func (h *Handlers) commenterContact(comment *models.Comment) (email, name string) {
+	if comment.UserID != nil {
+		if user, err := h.repo.GetUserByID(*comment.UserID); err == nil {
+			return user.Email, user.Name
+		}
+		return "", comment.Author
+	}
+	if !comment.EmailNotify {
+		return "", comment.Author
+	}
+	return comment.Email, comment.Author
+}
+
+func (h *Handlers) commentNotice(kind string, comment *models.Comment, article *models.Article) mail.Notice {
+	return mail.Notice{
+		Kind:         kind,
+		ArticleTitle: article.Title,
+		ArticleURL:   h.articleAppURL(article),
+		Actor:        comment.Author,
+		Excerpt:      truncateRunes(comment.Content, 200),
+	}
+}
+
+// emailCommentApproved 人工审核通过后通知评论者
+func (h *Handlers) emailCommentApproved(comment *models.Comment) {
+	article, err := h.repo.GetArticleByIDWithoutStatus(comment.ArticleID)
+	if err != nil {
+		return
+	}
+	notice := h.commentNotice(mail.KindCommentApproved, comment, article)
+	notice.To, notice.Name = h.commenterContact(comment)
+	h.sendMail(notice)
+}
+
+// emailCommentReply 回复公开后通知被回复的评论者（回复自己除外）
+func (h *Handlers) emailCommentReply(comment, parent *models.Comment, article *models.Article) {
+	if comment.UserID != nil && parent.UserID != nil && *comment.UserID == *parent.UserID {
+		return
+	}
+	notice := h.commentNotice(mail.KindCommentReply, comment, article)
+	notice.To, notice.Name = h.commenterContact(parent)
+
+	if email, _ := h.commenterContact(comment); strings.EqualFold(email, notice.To) {
+		return
+	}
+	h.sendMail(notice)
+}
+
+// emailCommentPending 新评论进入审核队列时通知文章作者
+func (h *Handlers) emailCommentPending(comment *models.Comment, article *models.Article) {
+	if article.AuthorID == nil {
+		return
+	}
+	author, err := h.repo.GetUserByID(*article.AuthorID)
+	if err != nil {
+		return
+	}
+	notice := h.commentNotice(mail.KindCommentPending, comment, article)
+	notice.To, notice.Name = author.Email, author.Name
+	h.sendMail(notice)
+}
+
+// unsubscribeConfirmTemplate 退订确认页，提交后以 POST 退订，避免邮件安全扫描等预取链接时误退订
+var unsubscribeConfirmTemplate = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="zh">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>退订邮件通知</title>
+</head>
+<body>
+<p>确认 {{.Email}} 不再接收邮件通知？</p>
+<form method="post" action="{{.Action}}">
+<button type="submit">确认退订</button>
+</form>
+</body>
+</html>
+`))
+
+// verifyUnsubscribeLink 校验退订链接中的邮箱和令牌
+func (h *Handlers) verifyUnsubscribeLink(c *gin.Context) (string, bool) {
+	email := c.Query("email")
+	if email == "" || !h.mailer.VerifyUnsubscribeToken(email, c.Query("token")) {
+		c.String(http.StatusBadRequest, "退订链接无效")
+		return "", false
+	}
+	return email, true
+}
+
+// ConfirmUnsubscribeEmail 点击邮件中的退订链接后显示确认页，不改变订阅状态
+func (h *Handlers) ConfirmUnsubscribeEmail(c *gin.Context) {
+	email, ok := h.verifyUnsubscribeLink(c)
+	if !ok {
+		return
+	}
+
+	var page bytes.Buffer
+	if err := unsubscribeConfirmTemplate.Execute(&page, gin.H{"Email": email, "Action": c.Request.URL.RequestURI()}); err != nil {
+		c.String(http.StatusInternalServerError, "页面生成失败，请稍后重试")
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
+
+// UnsubscribeEmail 退订所有邮件通知，由确认页提交或邮件客户端一键退订（List-Unsubscribe-Post）调用
+func (h *Handlers) UnsubscribeEmail(c *gin.Context) {
+	email, ok := h.verifyUnsubscribeLink(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.UnsubscribeEmail(email); err != nil {
+		c.String(http.StatusInternalServerError, "退订失败，请稍后重试")
+		return
+	}
+
+	c.String(http.StatusOK, "已退订，%s 将不再收到邮件通知。", email)
+}
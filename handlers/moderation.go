@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+	"github.com/ranp9275-sketch/blog-backend-golang/repository"
+	"github.com/ranp9275-sketch/blog-backend-golang/spam"
+)
+
+// 评论状态
+const (
+	CommentStatusPending  = "pending"
+	CommentStatusApproved = "approved"
+	CommentStatusRejected = "rejected"
+	CommentStatusSpam     = "spam"
+)
+
+// 评论类型
+const (
+	CommentTypeComment    = "comment"
+	CommentTypeWebmention = "webmention" // 其他站点通过 Webmention / Pingback 提及文章
+)
+
+// moderationActions 审核操作与目标状态的对应关系
+var moderationActions = map[string]string{
+	"approve": CommentStatusApproved,
+	"reject":  CommentStatusRejected,
+	"spam":    CommentStatusSpam,
+}
+
+// moderationComment 审核队列中的评论，附带不对外公开的邮箱和反垃圾判定结果
+type moderationComment struct {
+	models.Comment
+	Email       string  `json:"email"`
+	SpamScore   float64 `json:"spam_score"`
+	SpamReasons string  `json:"spam_reasons"`
+}
+
+// GetCommentsAdmin 管理员获取评论审核队列，默认只看待审核评论
+func (h *Handlers) GetCommentsAdmin(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	status := c.DefaultQuery("status", CommentStatusPending) // all 表示全部
+	articleID := c.Query("article_id")
+
+	if status == "all" {
+		status = ""
+	}
+
+	comments, total, err := h.repo.GetCommentsForModeration(page, pageSize, status, articleID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	data := make([]moderationComment, len(comments))
+	for i, comment := range comments {
+		data[i] = moderationComment{Comment: comment, Email: comment.Email, SpamScore: comment.SpamScore, SpamReasons: comment.SpamReasons}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     data,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// ApproveComment 通过评论
+func (h *Handlers) ApproveComment(c *gin.Context) {
+	h.moderateComment(c, CommentStatusApproved)
+}
+
+// RejectComment 拒绝评论
+func (h *Handlers) RejectComment(c *gin.Context) {
+	h.moderateComment(c, CommentStatusRejected)
+}
+
+// MarkCommentSpam 标记评论为垃圾评论
+func (h *Handlers) MarkCommentSpam(c *gin.Context) {
+	h.moderateComment(c, CommentStatusSpam)
+}
+
+func (h *Handlers) moderateComment(c *gin.Context, status string) {
+	id := c.Param("id")
+
+	comments, err := h.repo.GetCommentsByIDs([]string{id})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(comments) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return
+	}
+
+	if _, err := h.repo.UpdateCommentsStatus([]string{id}, status); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.trainSpamFilter(c.Request.Context(), comments, status)
+	h.notifyNewlyApproved(comments, status)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment " + status, "status": status})
+}
+
+// BulkModerateComments 批量审核评论：approve, reject, spam, delete
+func (h *Handlers) BulkModerateComments(c *gin.Context) {
+	var req struct {
+		IDs    []string `json:"ids" binding:"required,min=1,max=500"`
+		Action string   `json:"action" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Action == "delete" {
+		deleted, err := h.repo.DeleteComments(req.IDs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"action": req.Action, "affected": deleted})
+		return
+	}
+
+	status, ok := moderationActions[req.Action]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action"})
+		return
+	}
+
+	comments, err := h.repo.GetCommentsByIDs(req.IDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	affected, err := h.repo.UpdateCommentsStatus(req.IDs, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.trainSpamFilter(c.Request.Context(), comments, status)
+	h.notifyNewlyApproved(comments, status)
+
+	c.JSON(http.StatusOK, gin.H{"action": req.Action, "affected": affected})
+}
+
+// notifyNewlyApproved 审核通过此前未公开的评论时发送站内通知和邮件，并计入文章热度
+func (h *Handlers) notifyNewlyApproved(comments []models.Comment, status string) {
+	if status != CommentStatusApproved {
+		return
+	}
+	for i := range comments {
+		if comments[i].Status != CommentStatusApproved {
+			h.notifyCommentApproved(&comments[i])
+			h.emailCommentApproved(&comments[i])
+			h.repo.TrackTrending(comments[i].ArticleID, repository.TrendingWeightComment)
+		}
+	}
+}
+
+// spamInput 将评论转换为反垃圾检测输入
+func spamInput(comment *models.Comment) *spam.Input {
+	userID := ""
+	if comment.UserID != nil {
+		userID = *comment.UserID
+	}
+	return &spam.Input{
+		ArticleID: comment.ArticleID,
+		Author:    comment.Author,
+		Email:     comment.Email,
+		UserID:    userID,
+		Content:   comment.Content,
+		IP:        comment.IP,
+		UserAgent: comment.UserAgent,
+	}
+}
+
+// trainSpamFilter 根据管理员的审核结果训练反垃圾分类器：通过视为 ham，标记垃圾视为 spam；
+// 改判时先撤销之前的训练，避免重复计数
+func (h *Handlers) trainSpamFilter(ctx context.Context, comments []models.Comment, status string) {
+	class := ""
+	switch status {
+	case CommentStatusApproved:
+		class = spam.ClassHam
+	case CommentStatusSpam:
+		class = spam.ClassSpam
+	}
+
+	for i := range comments {
+		comment := &comments[i]
+		if comment.SpamTrained == class {
+			continue
+		}
+
+		input := spamInput(comment)
+		if comment.SpamTrained != "" {
+			if err := h.spamFilter.Untrain(ctx, input, comment.SpamTrained); err != nil {
+				log.Printf("[Spam] Failed to untrain comment %s: %v", comment.ID, err)
+				continue
+			}
+		}
+		// 每条评论单独记录训练结果，一条训练失败不影响批量中的其他评论
+		trained := class
+		if trained != "" {
+			if err := h.spamFilter.Train(ctx, input, trained); err != nil {
+				log.Printf("[Spam] Failed to train comment %s: %v", comment.ID, err)
+				trained = ""
+			}
+		}
+		h.repo.UpdateComment(comment.ID, map[string]interface{}{"spam_trained": trained})
+	}
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/ranp9275-sketch/blog-backend-golang/middleware"
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+	"github.com/ranp9275-sketch/blog-backend-golang/repository"
+)
+
+// 通知类型
+const (
+	NotificationComment         = "comment"          // 文章收到新评论
+	NotificationReply           = "reply"            // 评论收到回复
+	NotificationArticleRejected = "article_rejected" // 文章审核未通过
+	NotificationFavorite        = "favorite"         // 文章被收藏
+)
+
+const notificationHeartbeat = 30 * time.Second
+
+func newNotification(userID, kind, articleID, title, message string) models.Notification {
+	return models.Notification{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Type:      kind,
+		ArticleID: &articleID,
+		Title:     title,
+		Message:   message,
+		CreatedAt: time.Now(),
+	}
+}
+
+// notify 保存并推送通知，失败只记录日志，不影响主流程
+func (h *Handlers) notify(notifications ...models.Notification) {
+	if err := h.repo.CreateNotifications(notifications); err != nil {
+		log.Printf("[Notification] Failed to create notifications: %v", err)
+	}
+}
+
+// notifyCommentApproved 评论公开后通知文章作者和被回复的评论者
+func (h *Handlers) notifyCommentApproved(comment *models.Comment) {
+	article, err := h.repo.GetArticleByIDWithoutStatus(comment.ArticleID)
+	if err != nil {
+		return
+	}
+
+	excerpt := truncateRunes(comment.Content, 100)
+	recipients := map[string]models.Notification{}
+	if article.AuthorID != nil {
+		recipients[*article.AuthorID] = newNotification(*article.AuthorID, NotificationComment, article.ID,
+			fmt.Sprintf("%s 评论了你的文章《%s》", comment.Author, article.Title), excerpt)
+	}
+	// 回复登录用户的评论时通知对方，文章作者同时是被回复者时只发回复通知
+	var parent *models.Comment
+	if comment.ParentID != nil {
+		if parent, err = h.repo.GetCommentByID(*comment.ParentID); err != nil {
+			parent = nil
+		}
+	}
+	if parent != nil && parent.UserID != nil {
+		recipients[*parent.UserID] = newNotification(*parent.UserID, NotificationReply, article.ID,
+			fmt.Sprintf("%s 回复了你在《%s》下的评论", comment.Author, article.Title), excerpt)
+	}
+
+	notifications := make([]models.Notification, 0, len(recipients))
+	for userID, notification := range recipients {
+		// 不通知自己
+		if comment.UserID != nil && *comment.UserID == userID {
+			continue
+		}
+		notification.CommentID = &comment.ID
+		notification.ActorID = comment.UserID
+		notification.ActorName = comment.Author
+		notifications = append(notifications, notification)
+	}
+	h.notify(notifications...)
+
+	if parent != nil {
+		h.emailCommentReply(comment, parent, article)
+	}
+}
+
+// notifyArticleRejected 文章审核未通过时通知作者
+func (h *Handlers) notifyArticleRejected(article *models.Article, reason string) {
+	if article.AuthorID == nil {
+		return
+	}
+	message := reason
+	if message == "" {
+		message = "你的文章未通过审核，请修改后重新提交。"
+	}
+	h.notify(newNotification(*article.AuthorID, NotificationArticleRejected, article.ID,
+		fmt.Sprintf("你的文章《%s》未通过审核", article.Title), message))
+}
+
+// notifyArticleFavorited 文章被收藏时通知作者
+func (h *Handlers) notifyArticleFavorited(articleID, userID string) {
+	article, err := h.repo.GetArticleByIDWithoutStatus(articleID)
+	if err != nil || article.AuthorID == nil || *article.AuthorID == userID {
+		return
+	}
+	user, err := h.repo.GetUserByID(userID)
+	if err != nil {
+		return
+	}
+
+	notification := newNotification(*article.AuthorID, NotificationFavorite, article.ID,
+		fmt.Sprintf("%s 收藏了你的文章《%s》", user.Name, article.Title), "")
+	notification.ActorID = &user.ID
+	notification.ActorName = user.Name
+	h.notify(notification)
+}
+
+// GetNotifications 获取当前用户的通知列表及未读数，unread=true 时只返回未读通知
+func (h *Handlers) GetNotifications(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	unreadOnly := c.Query("unread") == "true"
+
+	notifications, total, err := h.repo.GetNotifications(userID.(string), page, pageSize, unreadOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	unread, _ := h.repo.CountUnreadNotifications(userID.(string))
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     notifications,
+		"total":    total,
+		"unread":   unread,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// MarkNotificationsRead 将指定通知标记为已读，不传 ids 时全部标记为已读
+func (h *Handlers) MarkNotificationsRead(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	var req struct {
+		IDs []string `json:"ids"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	affected, err := h.repo.MarkNotificationsRead(userID.(string), req.IDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	unread, _ := h.repo.CountUnreadNotifications(userID.(string))
+
+	c.JSON(http.StatusOK, gin.H{"affected": affected, "unread": unread})
+}
+
+// CreateStreamTicket 为通知推送连接签发一次性凭证，有效期 1 分钟
+func (h *Handlers) CreateStreamTicket(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	ticket, err := h.repo.CreateStreamTicket(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Notification stream unavailable"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ticket": ticket, "expires_in": int(repository.StreamTicketTTL.Seconds())})
+}
+
+// StreamAuthMiddleware 通知推送专用的认证中间件：浏览器 EventSource 无法设置请求头，允许通过 ?ticket= 传递一次性凭证，
+// 未携带凭证时按 AuthMiddleware 校验 Token；凭证从查询串中移除，不会写入访问日志。凭证只设置 userID，不能用于其他接口
+func (h *Handlers) StreamAuthMiddleware() gin.HandlerFunc {
+	auth := middleware.AuthMiddleware()
+	return func(c *gin.Context) {
+		query := c.Request.URL.Query()
+		ticket := query.Get("ticket")
+		if ticket == "" {
+			auth(c)
+			return
+		}
+		query.Del("ticket")
+		c.Request.URL.RawQuery = query.Encode()
+
+		userID, err := h.repo.RedeemStreamTicket(ticket)
+		if err != nil || userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired ticket"})
+			c.Abort()
+			return
+		}
+		c.Set("userID", userID)
+
+		c.Next()
+	}
+}
+
+// StreamNotifications 通过 Server-Sent Events 实时推送通知：
+// 连接建立时发送 unread 事件，之后每条新通知发送 notification 事件，并定期发送 ping 保活
+func (h *Handlers) StreamNotifications(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	pubsub := h.repo.SubscribeNotifications(ctx, userID.(string))
+	defer pubsub.Close()
+	if _, err := pubsub.Receive(ctx); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Notification stream unavailable"})
+		return
+	}
+	messages := pubsub.Channel()
+
+	heartbeat := time.NewTicker(notificationHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 关闭 Nginx 缓冲
+
+	unread, _ := h.repo.CountUnreadNotifications(userID.(string))
+	c.SSEvent("unread", gin.H{"unread": unread})
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case msg, ok := <-messages:
+			if !ok {
+				return false
+			}
+			c.SSEvent("notification", msg.Payload)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		}
+	})
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+	"github.com/ranp9275-sketch/blog-backend-golang/repository"
+)
+
+// commentReactionKinds 评论支持的表情回应
+var commentReactionKinds = repository.CommentReactionKinds
+
+func isCommentReactionKind(kind string) bool {
+	for _, k := range commentReactionKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// visitorID 访客标识：登录用户使用用户 ID，匿名访客使用 IP + UA 指纹
+func visitorID(c *gin.Context) string {
+	if userID, exists := c.Get("userID"); exists {
+		if uid, ok := userID.(string); ok && uid != "" {
+			return "user:" + uid
+		}
+	}
+	sum := sha256.Sum256([]byte(c.ClientIP() + "|" + c.Request.UserAgent()))
+	return "anon:" + hex.EncodeToString(sum[:16])
+}
+
+// strictVisitorID 用于计数的访客标识：登录用户按账号，匿名访客只按 IP，避免更换 User-Agent 刷举报人数、热门搜索词等
+func strictVisitorID(c *gin.Context) string {
+	if userID, exists := c.Get("userID"); exists {
+		if uid, ok := userID.(string); ok && uid != "" {
+			return "user:" + uid
+		}
+	}
+	sum := sha256.Sum256([]byte(c.ClientIP()))
+	return "ip:" + hex.EncodeToString(sum[:16])
+}
+
+// attachArticleLikes 附上文章点赞数及当前访客是否已点赞
+func (h *Handlers) attachArticleLikes(article *models.Article, actor string) {
+	ids := []string{article.ID}
+	kinds := []string{repository.ReactionLike}
+
+	counts, err := h.repo.CountReactions(repository.ReactionTargetArticle, ids, kinds)
+	if err != nil {
+		log.Printf("[Reaction] Failed to count likes for article %s: %v", article.ID, err)
+		return
+	}
+	article.LikeCount = counts[article.ID][repository.ReactionLike]
+
+	mine, _ := h.repo.ActorReactions(repository.ReactionTargetArticle, ids, kinds, actor)
+	article.Liked = len(mine[article.ID]) > 0
+}
+
+// attachCommentReactions 为评论树中的每条评论附上表情回应数及当前访客的回应
+func (h *Handlers) attachCommentReactions(comments []*models.Comment, actor string) {
+	var all []*models.Comment
+	var walk func(list []*models.Comment)
+	walk = func(list []*models.Comment) {
+		for _, comment := range list {
+			all = append(all, comment)
+			walk(comment.Replies)
+		}
+	}
+	walk(comments)
+	if len(all) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(all))
+	for _, comment := range all {
+		ids = append(ids, comment.ID)
+	}
+
+	counts, err := h.repo.CountReactions(repository.ReactionTargetComment, ids, commentReactionKinds)
+	if err != nil {
+		log.Printf("[Reaction] Failed to count comment reactions: %v", err)
+		return
+	}
+	mine, _ := h.repo.ActorReactions(repository.ReactionTargetComment, ids, commentReactionKinds, actor)
+
+	for _, comment := range all {
+		comment.Reactions = counts[comment.ID]
+		comment.MyReactions = mine[comment.ID]
+	}
+}
+
+// LikeArticle 点赞文章，同一访客重复点赞不计数
+func (h *Handlers) LikeArticle(c *gin.Context) {
+	h.toggleArticleLike(c, true)
+}
+
+// UnlikeArticle 取消点赞
+func (h *Handlers) UnlikeArticle(c *gin.Context) {
+	h.toggleArticleLike(c, false)
+}
+
+func (h *Handlers) toggleArticleLike(c *gin.Context, like bool) {
+	article, err := h.repo.GetArticleByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+		return
+	}
+
+	actor := visitorID(c)
+	if like {
+		_, err = h.repo.AddReaction(repository.ReactionTargetArticle, article.ID, repository.ReactionLike, actor)
+	} else {
+		_, err = h.repo.RemoveReaction(repository.ReactionTargetArticle, article.ID, repository.ReactionLike, actor)
+	}
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to update like"})
+		return
+	}
+
+	h.attachArticleLikes(article, actor)
+	c.JSON(http.StatusOK, gin.H{"liked": article.Liked, "like_count": article.LikeCount})
+}
+
+// AddCommentReaction 对评论做出表情回应
+func (h *Handlers) AddCommentReaction(c *gin.Context) {
+	var req struct {
+		Kind string `json:"kind" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.toggleCommentReaction(c, req.Kind, true)
+}
+
+// RemoveCommentReaction 撤销表情回应
+func (h *Handlers) RemoveCommentReaction(c *gin.Context) {
+	h.toggleCommentReaction(c, c.Param("kind"), false)
+}
+
+func (h *Handlers) toggleCommentReaction(c *gin.Context, kind string, add bool) {
+	if !isCommentReactionKind(kind) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported reaction"})
+		return
+	}
+
+	comment, err := h.repo.GetCommentByID(c.Param("id"))
+	if err != nil || comment.Status != CommentStatusApproved {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return
+	}
+
+	actor := visitorID(c)
+	if add {
+		_, err = h.repo.AddReaction(repository.ReactionTargetComment, comment.ID, kind, actor)
+	} else {
+		_, err = h.repo.RemoveReaction(repository.ReactionTargetComment, comment.ID, kind, actor)
+	}
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to update reaction"})
+		return
+	}
+
+	h.attachCommentReactions([]*models.Comment{comment}, actor)
+	c.JSON(http.StatusOK, gin.H{"reactions": comment.Reactions, "my_reactions": comment.MyReactions})
+}
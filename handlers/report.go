@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+	"github.com/ranp9275-sketch/blog-backend-golang/repository"
+)
+
+// reportReasons 可选的举报理由
+var reportReasons = map[string]bool{
+	"spam":          true, // 垃圾广告
+	"abuse":         true, // 辱骂攻击
+	"harassment":    true, // 骚扰
+	"plagiarism":    true, // 抄袭、未授权转载
+	"inappropriate": true, // 违法或不适宜内容
+	"other":         true,
+}
+
+// 举报处理操作
+const (
+	reportActionNone   = "none"   // 只关闭举报，不处理内容
+	reportActionHide   = "hide"   // 下架文章或拒绝评论
+	reportActionDelete = "delete" // 删除被举报的内容或用户
+)
+
+// reportTargetVisible 被举报对象是否存在且公开可见
+func (h *Handlers) reportTargetVisible(targetType, targetID string) bool {
+	switch targetType {
+	case repository.ReportTargetArticle:
+		article, err := h.repo.GetArticleByIDWithoutStatus(targetID)
+		return err == nil && article.Status == "published"
+	case repository.ReportTargetComment:
+		comment, err := h.repo.GetCommentByID(targetID)
+		return err == nil && comment.Status == CommentStatusApproved
+	case repository.ReportTargetUser:
+		_, err := h.repo.GetUserByID(targetID)
+		return err == nil
+	}
+	return false
+}
+
+// CreateReport 读者举报文章、评论或用户，同一对象的不同举报人达到阈值后自动隐藏内容等待管理员处理
+func (h *Handlers) CreateReport(c *gin.Context) {
+	var req struct {
+		TargetType string `json:"target_type" binding:"required,oneof=article comment user"`
+		TargetID   string `json:"target_id" binding:"required"`
+		Reason     string `json:"reason" binding:"required"`
+		Detail     string `json:"detail" binding:"max=1000"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !reportReasons[req.Reason] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reason"})
+		return
+	}
+
+	var reporterID *string
+	if userID, exists := c.Get("userID"); exists {
+		uid := userID.(string)
+		if req.TargetType == repository.ReportTargetUser && req.TargetID == uid {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot report yourself"})
+			return
+		}
+		reporterID = &uid
+	}
+
+	if !h.reportTargetVisible(req.TargetType, req.TargetID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report target not found"})
+		return
+	}
+
+	report := &models.Report{
+		ID:         uuid.New().String(),
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		Reporter:   strictVisitorID(c),
+		ReporterID: reporterID,
+		Reason:     req.Reason,
+		Detail:     req.Detail,
+		Status:     repository.ReportStatusOpen,
+		CreatedAt:  time.Now(),
+	}
+	created, hidden, err := h.repo.CreateReport(report, h.settings.ReportHide)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if hidden && req.TargetType == repository.ReportTargetArticle {
+		h.indexArticles(req.TargetID)
+	}
+	if !created {
+		c.JSON(http.StatusOK, gin.H{"message": "Already reported"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Report submitted", "id": report.ID})
+}
+
+// GetReports 管理员获取举报处理队列，按被举报对象分组，默认只看未处理的举报
+func (h *Handlers) GetReports(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	status := c.DefaultQuery("status", repository.ReportStatusOpen) // all 表示全部
+	targetType := c.Query("target_type")
+
+	if status == "all" {
+		status = ""
+	}
+
+	groups, total, err := h.repo.GetReportQueue(page, pageSize, status, targetType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     groups,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// getOpenReport 获取待处理的举报，不存在或已处理时直接写入错误响应
+func (h *Handlers) getOpenReport(c *gin.Context) (*models.Report, bool) {
+	report, err := h.repo.GetReportByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return nil, false
+	}
+	if report.Status != repository.ReportStatusOpen {
+		c.JSON(http.StatusConflict, gin.H{"error": "Report already handled"})
+		return nil, false
+	}
+	return report, true
+}
+
+// ResolveReport 确认举报成立并处理被举报对象，同一对象的所有未处理举报一并关闭
+func (h *Handlers) ResolveReport(c *gin.Context) {
+	var req struct {
+		Action     string `json:"action"`
+		Resolution string `json:"resolution"`
+	}
+	c.ShouldBindJSON(&req)
+	if req.Action == "" {
+		req.Action = reportActionNone
+	}
+
+	report, ok := h.getOpenReport(c)
+	if !ok {
+		return
+	}
+	adminID, _ := c.Get("userID")
+
+	var err error
+	switch {
+	case req.Action == reportActionNone:
+	case req.Action == reportActionHide && report.TargetType == repository.ReportTargetComment:
+		_, err = h.repo.UpdateCommentsStatus([]string{report.TargetID}, CommentStatusRejected)
+	case req.Action == reportActionHide && report.TargetType == repository.ReportTargetArticle:
+		var article *models.Article
+		if article, err = h.repo.GetArticleByIDWithoutStatus(report.TargetID); err == nil {
+			if err = h.repo.UpdateArticle(article.ID, map[string]interface{}{
+				"status":     "rejected",
+				"updated_at": time.Now(),
+			}); err == nil {
+				h.notifyArticleRejected(article, req.Resolution)
+			}
+		}
+	case req.Action == reportActionDelete && report.TargetType == repository.ReportTargetComment:
+		err = h.repo.DeleteComment(report.TargetID)
+	case req.Action == reportActionDelete && report.TargetType == repository.ReportTargetArticle:
+		err = h.repo.DeleteArticle(report.TargetID)
+	case req.Action == reportActionDelete && report.TargetType == repository.ReportTargetUser:
+		if report.TargetID == adminID.(string) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot delete yourself"})
+			return
+		}
+		err = h.repo.DeleteUser(report.TargetID)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if report.TargetType == repository.ReportTargetArticle {
+		h.indexArticles(report.TargetID)
+	}
+
+	// 删除对象时举报随之删除
+	var closed int64
+	if req.Action != reportActionDelete {
+		closed, err = h.repo.CloseReports(report.TargetType, report.TargetID,
+			repository.ReportStatusResolved, adminID.(string), req.Resolution)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Report resolved", "action": req.Action, "closed": closed})
+}
+
+// DismissReport 驳回举报，被自动隐藏的内容恢复原状态，同一对象的所有未处理举报一并关闭
+func (h *Handlers) DismissReport(c *gin.Context) {
+	var req struct {
+		Resolution string `json:"resolution"`
+	}
+	c.ShouldBindJSON(&req)
+
+	report, ok := h.getOpenReport(c)
+	if !ok {
+		return
+	}
+	adminID, _ := c.Get("userID")
+
+	reports, err := h.repo.GetOpenReports(report.TargetType, report.TargetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, r := range reports {
+		if r.HiddenFrom == "" {
+			continue
+		}
+		if err := h.repo.RestoreReportTarget(r.TargetType, r.TargetID, r.HiddenFrom); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if r.TargetType == repository.ReportTargetArticle {
+			h.indexArticles(r.TargetID)
+		}
+		break
+	}
+
+	closed, err := h.repo.CloseReports(report.TargetType, report.TargetID,
+		repository.ReportStatusDismissed, adminID.(string), req.Resolution)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Report dismissed", "closed": closed})
+}
@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+	"github.com/ranp9275-sketch/blog-backend-golang/repository"
+	"github.com/ranp9275-sketch/blog-backend-golang/search"
+	"gorm.io/gorm"
+)
+
+const (
+	searchMaxPageSize  = 50
+	searchRebuildBatch = 100
+	suggestLimit       = 5 // 每个类别默认返回的建议数
+)
+
+// searchHit 带高亮片段和相关度的搜索结果
+type searchHit struct {
+	models.Article
+	Score      float64             `json:"score"`
+	Highlights map[string][]string `json:"highlights"`
+}
+
+// searchFacet 分面中的一个取值，附带分类、标签或作者的名称
+type searchFacet struct {
+	Value string `json:"value"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// articleDocument 文章在全文索引中的文档，正文转为纯文本
+func articleDocument(article *models.Article) *search.Document {
+	doc := &search.Document{
+		Title:   article.Title,
+		Excerpt: article.Excerpt,
+		Content: markdownToPlainText(article.Content),
+		Tags:    make([]string, len(article.Tags)),
+	}
+	if article.CategoryID != nil {
+		doc.Category = *article.CategoryID
+	}
+	if article.AuthorID != nil {
+		doc.Author = *article.AuthorID
+	}
+	for i, tag := range article.Tags {
+		doc.Tags[i] = tag.ID
+	}
+	if article.PublishedAt != nil {
+		doc.PublishedAt = *article.PublishedAt
+		doc.Year = strconv.Itoa(article.PublishedAt.Year())
+	}
+	return doc
+}
+
+// indexArticles 通知所有实例将文章同步到全文索引：已发布的文章写入，其他状态或已删除的文章移出索引；
+// Redis 不可用时只更新当前实例
+func (h *Handlers) indexArticles(ids ...string) {
+	if h.index == nil || len(ids) == 0 {
+		return
+	}
+	if err := h.repo.PublishSearchUpdate(repository.SearchUpdate{IDs: ids}); err != nil {
+		log.Printf("[Search] Failed to publish index update, updating local index only: %v", err)
+		go h.syncArticles(ids)
+	}
+}
+
+func (h *Handlers) syncArticles(ids []string) {
+	for _, id := range ids {
+		if err := h.syncArticleIndex(id); err != nil {
+			log.Printf("[Search] Failed to index article %s: %v", id, err)
+		}
+	}
+}
+
+func (h *Handlers) syncArticleIndex(id string) error {
+	article, err := h.repo.GetArticleByIDWithoutStatus(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return h.index.Delete(id)
+	}
+	if err != nil {
+		return err
+	}
+	if article.Status != "published" {
+		return h.index.Delete(id)
+	}
+	return h.index.Index(id, articleDocument(article))
+}
+
+// RebuildSearchIndex 从数据库重建全文索引，返回索引的文章数；未启用全文索引时不做任何事
+func (h *Handlers) RebuildSearchIndex() (int, error) {
+	if h.index == nil {
+		return 0, nil
+	}
+	return h.index.Rebuild(func(add func(id string, doc *search.Document) error) error {
+		return h.repo.EachPublishedArticle(searchRebuildBatch, func(articles []models.Article) error {
+			for i := range articles {
+				if err := add(articles[i].ID, articleDocument(&articles[i])); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// rebuildSearchIndex 在后台重建当前实例的全文索引并记录结果
+func (h *Handlers) rebuildSearchIndex() {
+	count, err := h.RebuildSearchIndex()
+	if errors.Is(err, search.ErrRebuilding) {
+		return
+	}
+	if err != nil {
+		log.Printf("[Search] Failed to rebuild search index: %v", err)
+		return
+	}
+	log.Printf("[Search] Rebuilt search index with %d articles", count)
+}
+
+// RunSearchSync 订阅全文索引更新消息并应用到当前实例的索引，阻塞直到 ctx 取消；
+// 订阅断开期间的消息会丢失，可通过重建索引补齐
+func (h *Handlers) RunSearchSync(ctx context.Context) {
+	if h.index == nil {
+		return
+	}
+	pubsub := h.repo.SubscribeSearchUpdates(ctx)
+	defer pubsub.Close()
+	messages := pubsub.Channel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var update repository.SearchUpdate
+			if err := json.Unmarshal([]byte(msg.Payload), &update); err != nil {
+				log.Printf("[Search] Invalid index update: %v", err)
+				continue
+			}
+			if update.Rebuild {
+				go h.rebuildSearchIndex()
+			}
+			// 按收到的顺序依次同步，同一篇文章的多次更新不会乱序
+			h.syncArticles(update.IDs)
+		}
+	}
+}
+
+// ReindexSearch 管理员在后台重建所有实例的全文索引
+func (h *Handlers) ReindexSearch(c *gin.Context) {
+	if h.index == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Search index is disabled"})
+		return
+	}
+	if h.index.Rebuilding() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Search index is already being rebuilt"})
+		return
+	}
+
+	if err := h.repo.PublishSearchUpdate(repository.SearchUpdate{Rebuild: true}); err != nil {
+		log.Printf("[Search] Failed to publish index rebuild, rebuilding local index only: %v", err)
+		go h.rebuildSearchIndex()
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Search index rebuild started"})
+}
+
+// SearchArticles 搜索已发布文章，可按分类、标签、作者和发布年份筛选；
+// 启用全文索引时返回高亮片段和分面统计，索引不可用时退化为数据库搜索
+func (h *Handlers) SearchArticles(c *gin.Context) {
+	query := c.Query("q")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query is required"})
+		return
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > searchMaxPageSize {
+		pageSize = 10
+	}
+
+	filters := search.Filters{
+		Category: c.Query("category_id"),
+		Tag:      c.Query("tag_id"),
+		Author:   c.Query("author_id"),
+		Year:     c.Query("year"),
+	}
+	if filters.Year != "" {
+		if year, err := strconv.Atoi(filters.Year); err != nil || year < 1 || year > 9999 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+			return
+		}
+	}
+
+	if h.index != nil {
+		q := search.ParseQuery(query)
+		if len(q.Groups) == 0 {
+			c.JSON(http.StatusOK, gin.H{"data": []searchHit{}, "total": 0, "page": page, "pageSize": pageSize, "facets": gin.H{}})
+			return
+		}
+		result, err := h.index.Search(q, filters, page, pageSize)
+		if err == nil {
+			h.trackSearchQuery(c, query, page, int64(result.Total))
+			h.respondIndexSearch(c, result, page, pageSize)
+			return
+		}
+		log.Printf("[Search] Index search failed, falling back to database search: %v", err)
+	}
+
+	articles, total, err := h.repo.SearchArticles(query, filters, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.trackSearchQuery(c, query, page, total)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     articles,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// trackSearchQuery 按访客统计有结果的搜索词，翻页不重复计数
+func (h *Handlers) trackSearchQuery(c *gin.Context, query string, page int, total int64) {
+	if page == 1 && total > 0 {
+		h.repo.TrackSearchQuery(query, strictVisitorID(c))
+	}
+}
+
+// SuggestSearch 搜索框输入提示：标题以输入开头的文章、名称匹配的标签和分类以及热门搜索词，
+// 从内存前缀树中查询，不访问数据库
+func (h *Handlers) SuggestSearch(c *gin.Context) {
+	query := c.Query("q")
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(suggestLimit)))
+	if err != nil || limit < 1 {
+		limit = suggestLimit
+	}
+
+	suggestions := h.suggester.Suggest(query, limit)
+	c.JSON(http.StatusOK, gin.H{
+		"query":      query,
+		"articles":   suggestions[search.SuggestArticle],
+		"tags":       suggestions[search.SuggestTag],
+		"categories": suggestions[search.SuggestCategory],
+		"queries":    suggestions[search.SuggestQuery],
+	})
+}
+
+// respondIndexSearch 按命中顺序加载文章，并为分面补充名称
+func (h *Handlers) respondIndexSearch(c *gin.Context, result *search.Result, page, pageSize int) {
+	ids := make([]string, len(result.Hits))
+	for i, hit := range result.Hits {
+		ids[i] = hit.ID
+	}
+	// 索引异步更新，刚下线的文章可能仍在索引中，加载时会被排除
+	articles, err := h.repo.GetPublishedArticlesByIDs(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	byID := make(map[string]models.Article, len(articles))
+	for _, article := range articles {
+		byID[article.ID] = article
+	}
+	hits := []searchHit{}
+	for _, hit := range result.Hits {
+		if article, ok := byID[hit.ID]; ok {
+			hits = append(hits, searchHit{Article: article, Score: hit.Score, Highlights: hit.Highlights})
+		}
+	}
+
+	facets, err := h.searchFacets(result.Facets)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     hits,
+		"total":    result.Total,
+		"page":     page,
+		"pageSize": pageSize,
+		"facets":   facets,
+	})
+}
+
+// searchFacets 分面取值是 ID，替换为当前的分类、标签和作者名称；年份以自身为名称
+func (h *Handlers) searchFacets(facets map[string][]search.Facet) (map[string][]searchFacet, error) {
+	names := map[string]map[string]string{
+		search.FacetCategory: {},
+		search.FacetTag:      {},
+		search.FacetAuthor:   {},
+	}
+	if len(facets[search.FacetCategory]) > 0 {
+		categories, err := h.repo.GetCategories()
+		if err != nil {
+			return nil, err
+		}
+		for _, category := range categories {
+			names[search.FacetCategory][category.ID] = category.Name
+		}
+	}
+	if len(facets[search.FacetTag]) > 0 {
+		tags, err := h.repo.GetTags()
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range tags {
+			names[search.FacetTag][tag.ID] = tag.Name
+		}
+	}
+	if len(facets[search.FacetAuthor]) > 0 {
+		ids := make([]string, len(facets[search.FacetAuthor]))
+		for i, facet := range facets[search.FacetAuthor] {
+			ids[i] = facet.Value
+		}
+		users, err := h.repo.GetUsersByIDs(ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			names[search.FacetAuthor][user.ID] = user.Name
+		}
+	}
+
+	result := map[string][]searchFacet{}
+	for field, values := range facets {
+		result[field] = []searchFacet{}
+		for _, facet := range values {
+			name := facet.Value
+			if fieldNames, ok := names[field]; ok {
+				// 分类、标签或作者已被删除，等待重建索引后消失
+				if name, ok = fieldNames[facet.Value]; !ok {
+					continue
+				}
+			}
+			result[field] = append(result[field], searchFacet{Value: facet.Value, Name: name, Count: facet.Count})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+)
+
+// SEOMeta 文章最终生效的 SEO 元数据
+type SEOMeta struct {
+	Title         string
+	Description   string
+	CanonicalURL  string
+	Image         string
+	Locale        string
+	SiteName      string
+	AppURL        string
+	PublishedTime string
+	ModifiedTime  string
+	Tags          []string
+}
+
+// articleShellData 文章页外壳的渲染数据
+type articleShellData struct {
+	SEOMeta
+	AuthorName    string
+	Links         []string
+	WebmentionURL string
+	PingbackURL   string
+}
+
+// SEOFields 用户编辑文章时可填写的 SEO 字段
+type SEOFields struct {
+	MetaTitle       string `json:"meta_title"`
+	MetaDescription string `json:"meta_description"`
+	CanonicalURL    string `json:"canonical_url"`
+	OGImage         string `json:"og_image"`
+}
+
+// applyTo 将非空的 SEO 字段写入更新集合
+func (f SEOFields) applyTo(updates map[string]interface{}) {
+	if f.MetaTitle != "" {
+		updates["meta_title"] = f.MetaTitle
+	}
+	if f.MetaDescription != "" {
+		updates["meta_description"] = f.MetaDescription
+	}
+	if f.CanonicalURL != "" {
+		updates["canonical_url"] = f.CanonicalURL
+	}
+	if f.OGImage != "" {
+		updates["og_image"] = f.OGImage
+	}
+}
+
+var articleShellTemplate = template.Must(template.New("article").Parse(`<!DOCTYPE html>
+<html lang="{{.Locale}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<meta name="description" content="{{.Description}}">
+<link rel="canonical" href="{{.CanonicalURL}}">
+<meta property="og:type" content="article">
+<meta property="og:title" content="{{.Title}}">
+<meta property="og:description" content="{{.Description}}">
+<meta property="og:url" content="{{.CanonicalURL}}">
+{{- if .SiteName}}
+<meta property="og:site_name" content="{{.SiteName}}">
+{{- end}}
+{{- if .Image}}
+<meta property="og:image" content="{{.Image}}">
+{{- end}}
+<meta property="og:locale" content="{{if eq .Locale "en"}}en_US{{else}}zh_CN{{end}}">
+{{- if .PublishedTime}}
+<meta property="article:published_time" content="{{.PublishedTime}}">
+{{- end}}
+<meta property="article:modified_time" content="{{.ModifiedTime}}">
+{{- range .Tags}}
+<meta property="article:tag" content="{{.}}">
+{{- end}}
+<meta name="twitter:card" content="{{if .Image}}summary_large_image{{else}}summary{{end}}">
+<meta name="twitter:title" content="{{.Title}}">
+<meta name="twitter:description" content="{{.Description}}">
+{{- if .Image}}
+<meta name="twitter:image" content="{{.Image}}">
+{{- end}}
+<meta itemprop="name" content="{{.Title}}">
+<meta itemprop="description" content="{{.Description}}">
+{{- if .Image}}
+<meta itemprop="image" content="{{.Image}}">
+{{- end}}
+{{- if .WebmentionURL}}
+<link rel="webmention" href="{{.WebmentionURL}}">
+<link rel="pingback" href="{{.PingbackURL}}">
+{{- end}}
+<script>window.location.replace({{.AppURL}});</script>
+</head>
+<body>
+<article class="h-entry">
+<h1 class="p-name">{{.Title}}</h1>
+{{- if .AuthorName}}
+<p class="p-author h-card">{{.AuthorName}}</p>
+{{- end}}
+<p class="p-summary">{{.Description}}</p>
+{{- if .Links}}
+<ul class="e-content">
+{{- range .Links}}
+<li><a href="{{.}}">{{.}}</a></li>
+{{- end}}
+</ul>
+{{- end}}
+<noscript><a class="u-url" href="{{.AppURL}}">{{.Title}}</a></noscript>
+</article>
+</body>
+</html>
+`))
+
+// siteURL 站点对外访问地址，用于生成绝对链接
+func (h *Handlers) siteURL() string {
+	return strings.TrimRight(h.settings.SiteURL, "/")
+}
+
+// absoluteURL 将 /uploads/... 之类的站内路径补全为绝对地址
+func (h *Handlers) absoluteURL(path string) string {
+	if path == "" || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	if strings.HasPrefix(path, "//") {
+		return "https:" + path
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return h.siteURL() + path
+}
+
+// articlePath 文章对外使用的路径标识，优先 slug
+func articlePath(article *models.Article) string {
+	if article.Slug != "" {
+		return article.Slug
+	}
+	return article.ID
+}
+
+// articleAppURL 前端 SPA 中的文章地址，SPA_ARTICLE_PATH 支持 :id 和 :slug 占位符
+func (h *Handlers) articleAppURL(article *models.Article) string {
+	pattern := h.settings.SPAArticlePath
+	if pattern == "" {
+		pattern = "/article/:id"
+	}
+	path := strings.NewReplacer(":id", article.ID, ":slug", articlePath(article)).Replace(pattern)
+	return h.absoluteURL(path)
+}
+
+// articleSEO 计算文章生效的 SEO 元数据，未填写的字段回退到标题、摘要和封面
+func (h *Handlers) articleSEO(article *models.Article) SEOMeta {
+	meta := SEOMeta{
+		Title:        article.MetaTitle,
+		Description:  article.MetaDescription,
+		CanonicalURL: article.CanonicalURL,
+		Image:        article.OGImage,
+		Locale:       article.Locale,
+		SiteName:     h.settings.SiteName,
+		AppURL:       h.articleAppURL(article),
+		ModifiedTime: article.UpdatedAt.Format(time.RFC3339),
+	}
+
+	if meta.Title == "" {
+		meta.Title = article.Title
+	}
+	if meta.Description == "" {
+		meta.Description = article.Excerpt
+	}
+	if meta.Description == "" {
+		meta.Description = markdownToPlainText(article.Content)
+	}
+	meta.Description = truncateRunes(meta.Description, 160)
+	if meta.CanonicalURL == "" {
+		meta.CanonicalURL = h.absoluteURL("/a/" + articlePath(article))
+	}
+	if meta.Image == "" {
+		meta.Image = article.CoverImage
+	}
+	meta.Image = h.absoluteURL(meta.Image)
+	if meta.Locale == "" {
+		meta.Locale = defaultLocale
+	}
+	if article.PublishedAt != nil {
+		meta.PublishedTime = article.PublishedAt.Format(time.RFC3339)
+	}
+	for _, tag := range article.Tags {
+		meta.Tags = append(meta.Tags, tag.Name)
+	}
+
+	return meta
+}
+
+// RenderArticleShell 服务端渲染带 meta 标签的文章页外壳，供爬虫抓取分享预览，浏览器随后跳转到 SPA
+func (h *Handlers) RenderArticleShell(c *gin.Context) {
+	slug := c.Param("slug")
+
+	article, err := h.repo.GetPublishedArticleBySlug(slug)
+	if err != nil {
+		c.String(http.StatusNotFound, "Article not found")
+		return
+	}
+
+	// 页面以 h-entry 列出正文中的外链，便于被提及方抓取验证 Webmention
+	data := articleShellData{
+		SEOMeta:    h.articleSEO(article),
+		AuthorName: article.Author.Name,
+		Links:      h.outboundLinks(article.Content),
+	}
+	if h.siteURL() != "" {
+		data.WebmentionURL = h.absoluteURL("/api/webmention")
+		data.PingbackURL = h.absoluteURL("/api/pingback")
+	}
+
+	// 先渲染到缓冲区，渲染失败时不会返回被缓存的半截页面
+	var page bytes.Buffer
+	if err := articleShellTemplate.Execute(&page, data); err != nil {
+		c.String(http.StatusInternalServerError, "Failed to render page")
+		return
+	}
+
+	if data.WebmentionURL != "" {
+		c.Header("Link", "<"+data.WebmentionURL+`>; rel="webmention"`)
+		c.Header("X-Pingback", data.PingbackURL)
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+)
+
+// ArticleTemplateRequest 创建模板请求
+type ArticleTemplateRequest struct {
+	Name              string   `json:"name" binding:"required"`
+	Description       string   `json:"description"`
+	TitlePattern      string   `json:"title_pattern"`
+	ContentSkeleton   string   `json:"content_skeleton"`
+	DefaultCategoryID string   `json:"default_category_id"`
+	TagIDs            []string `json:"tag_ids"`
+}
+
+// GetArticleTemplates 获取文章模板列表
+func (h *Handlers) GetArticleTemplates(c *gin.Context) {
+	templates, err := h.repo.GetArticleTemplates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// CreateArticleTemplate 创建文章模板
+func (h *Handlers) CreateArticleTemplate(c *gin.Context) {
+	var req ArticleTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var categoryIDPtr *string
+	if req.DefaultCategoryID != "" {
+		categoryIDPtr = &req.DefaultCategoryID
+	}
+
+	template := &models.ArticleTemplate{
+		ID:                uuid.New().String(),
+		Name:              req.Name,
+		Description:       req.Description,
+		TitlePattern:      req.TitlePattern,
+		ContentSkeleton:   req.ContentSkeleton,
+		DefaultCategoryID: categoryIDPtr,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	}
+
+	if err := h.repo.CreateArticleTemplate(template, req.TagIDs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// UpdateArticleTemplate 更新文章模板
+func (h *Handlers) UpdateArticleTemplate(c *gin.Context) {
+	id := c.Param("id")
+	var updates map[string]interface{}
+
+	if err := c.ShouldBindJSON(&updates); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 标签通过关联表单独处理
+	var tagIDs []string
+	if val, ok := updates["tag_ids"]; ok {
+		tagIDs = []string{}
+		if list, ok := val.([]interface{}); ok {
+			for _, item := range list {
+				if s, ok := item.(string); ok && s != "" {
+					tagIDs = append(tagIDs, s)
+				}
+			}
+		}
+		delete(updates, "tag_ids")
+	}
+
+	// 处理空字符串转为 NULL
+	if val, ok := updates["default_category_id"]; ok {
+		if s, ok := val.(string); ok && s == "" {
+			updates["default_category_id"] = nil
+		}
+	}
+
+	updates["updated_at"] = time.Now()
+
+	if err := h.repo.UpdateArticleTemplate(id, updates, tagIDs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Template updated"})
+}
+
+// DeleteArticleTemplate 删除文章模板
+func (h *Handlers) DeleteArticleTemplate(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.repo.DeleteArticleTemplate(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Template deleted"})
+}
+
+// templateVars 生成模板变量：日期、作者名、周数等
+func templateVars(authorName string, now time.Time) map[string]string {
+	_, week := now.ISOWeek()
+	return map[string]string{
+		"date":   now.Format("2006-01-02"),
+		"year":   strconv.Itoa(now.Year()),
+		"month":  now.Format("01"),
+		"week":   strconv.Itoa(week),
+		"author": authorName,
+	}
+}
+
+// renderTemplate 替换文本中的 {{变量}}，未知变量保持原样
+func renderTemplate(text string, vars map[string]string) string {
+	if text == "" {
+		return text
+	}
+
+	pairs := make([]string, 0, len(vars)*4)
+	for key, val := range vars {
+		pairs = append(pairs, "{{"+key+"}}", val, "{{ "+key+" }}", val)
+	}
+
+	return strings.NewReplacer(pairs...).Replace(text)
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+)
+
+const (
+	excerptLength   = 200 // 自动摘要长度（字符）
+	keywordLimit    = 8   // 最多提取的关键词数量
+	cjkCharsPerMin  = 300 // 中文阅读速度（字/分钟）
+	wordsPerMinute  = 200 // 英文阅读速度（词/分钟）
+	titleTermWeight = 3   // 标题中出现的词权重
+)
+
+var (
+	markdownCodeFenceRe = regexp.MustCompile("(?s)```.*?```|~~~.*?~~~")
+	markdownImageRe     = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	markdownLinkRe      = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	markdownSyntaxRe    = regexp.MustCompile("(?m)^\\s{0,3}(#{1,6}|>|[-*+]|\\d+\\.)\\s+|[`*_~]|<!--[\\s\\S]*?-->|<[^>]+>|^\\s*(-{3,}|\\|.*\\|)\\s*$")
+	whitespaceRe        = regexp.MustCompile(`\s+`)
+)
+
+// englishStopWords 英文常见停用词
+var englishStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "but": true, "not": true, "you": true,
+	"all": true, "can": true, "was": true, "one": true, "our": true, "has": true, "have": true,
+	"this": true, "that": true, "with": true, "from": true, "they": true, "will": true, "your": true,
+	"what": true, "when": true, "which": true, "there": true, "their": true, "been": true, "more": true,
+	"into": true, "than": true, "then": true, "them": true, "these": true, "some": true, "would": true,
+	"about": true, "also": true, "how": true, "its": true, "use": true, "using": true, "used": true,
+	"just": true, "like": true, "only": true, "other": true, "such": true, "each": true, "may": true,
+	"any": true, "out": true, "get": true, "www": true, "http": true, "https": true, "com": true,
+}
+
+// cjkStopChars 含有这些虚字的中文二元词不作为关键词
+var cjkStopChars = map[rune]bool{
+	'的': true, '了': true, '是': true, '在': true, '和': true, '与': true, '或': true, '及': true,
+	'也': true, '就': true, '都': true, '而': true, '被': true, '把': true, '让': true, '这': true,
+	'那': true, '我': true, '你': true, '他': true, '她': true, '它': true, '们': true, '个': true,
+	'有': true, '不': true, '之': true, '为': true, '以': true, '于': true, '上': true, '中': true,
+	'下': true, '对': true, '将': true, '从': true, '到': true, '说': true, '要': true, '会': true,
+	'可': true, '能': true, '还': true, '很': true, '吗': true, '呢': true, '吧': true, '啊': true,
+}
+
+// TextStats 文章正文分析结果
+type TextStats struct {
+	Excerpt     string
+	WordCount   int
+	ReadingTime int // 分钟
+	Keywords    []string
+}
+
+// markdownToPlainText 粗略去除 Markdown 语法（含代码块），得到纯文本
+func markdownToPlainText(content string) string {
+	text := markdownCodeFenceRe.ReplaceAllString(content, " ")
+	text = markdownImageRe.ReplaceAllString(text, "")
+	text = markdownLinkRe.ReplaceAllString(text, "$1")
+	text = markdownSyntaxRe.ReplaceAllString(text, "")
+	return strings.TrimSpace(whitespaceRe.ReplaceAllString(text, " "))
+}
+
+// truncateRunes 按字符截断文本，超出时追加省略号
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:limit])) + "…"
+}
+
+// isCJK 判断是否为中日韩文字
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+// countWords 统计字数：每个中日韩字符计一个字，连续的字母数字计一个词
+func countWords(text string) (cjk, words int) {
+	inWord := false
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			cjk++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				words++
+				inWord = true
+			}
+		default:
+			inWord = false
+		}
+	}
+	return cjk, words
+}
+
+// tokenize 切分关键词候选：英文按单词，中文按相邻二字组合
+func tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var han []rune
+
+	flushWord := func() {
+		if len(word) >= 2 {
+			w := strings.ToLower(string(word))
+			if !englishStopWords[w] && !isNumeric(w) {
+				tokens = append(tokens, w)
+			}
+		}
+		word = word[:0]
+	}
+	flushHan := func() {
+		for i := 0; i+1 < len(han); i++ {
+			if cjkStopChars[han[i]] || cjkStopChars[han[i+1]] {
+				continue
+			}
+			tokens = append(tokens, string(han[i:i+2]))
+		}
+		han = han[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '+' || r == '#':
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+
+	return tokens
+}
+
+func isNumeric(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// extractKeywords 按词频提取关键词，标题中的词加权
+func extractKeywords(title, plainText string, limit int) []string {
+	freq := map[string]int{}
+	for _, token := range tokenize(plainText) {
+		freq[token]++
+	}
+	for _, token := range tokenize(title) {
+		freq[token] += titleTermWeight
+	}
+
+	type termFreq struct {
+		term  string
+		count int
+	}
+	terms := make([]termFreq, 0, len(freq))
+	for term, count := range freq {
+		// 只出现一次的词噪声较大
+		if count < 2 {
+			continue
+		}
+		terms = append(terms, termFreq{term, count})
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].count != terms[j].count {
+			return terms[i].count > terms[j].count
+		}
+		return terms[i].term < terms[j].term
+	})
+
+	// 为每个字记录以其开头/结尾的中文二元词的最高频次，用于过滤跨词边界的组合（如 "并发" + "编程" 产生的 "发编"）
+	maxHead := map[rune]int{}
+	maxTail := map[rune]int{}
+	for _, t := range terms {
+		if runes := []rune(t.term); len(runes) == 2 && unicode.Is(unicode.Han, runes[0]) {
+			if t.count > maxHead[runes[0]] {
+				maxHead[runes[0]] = t.count
+			}
+			if t.count > maxTail[runes[1]] {
+				maxTail[runes[1]] = t.count
+			}
+		}
+	}
+
+	keywords := make([]string, 0, limit)
+	for _, t := range terms {
+		if len(keywords) >= limit {
+			break
+		}
+		if runes := []rune(t.term); len(runes) == 2 && unicode.Is(unicode.Han, runes[0]) {
+			if maxTail[runes[0]] >= t.count && maxHead[runes[1]] >= t.count {
+				continue
+			}
+		}
+		keywords = append(keywords, t.term)
+	}
+	return keywords
+}
+
+// analyzeContent 从 Markdown 正文生成摘要、字数、阅读时长和关键词
+func analyzeContent(title, content string) TextStats {
+	plain := markdownToPlainText(content)
+	cjk, words := countWords(plain)
+
+	stats := TextStats{
+		Excerpt:   truncateRunes(plain, excerptLength),
+		WordCount: cjk + words,
+		Keywords:  extractKeywords(title, plain, keywordLimit),
+	}
+	if stats.WordCount > 0 {
+		minutes := float64(cjk)/cjkCharsPerMin + float64(words)/wordsPerMinute
+		stats.ReadingTime = int(math.Max(1, math.Ceil(minutes)))
+	}
+	return stats
+}
+
+// applyTextStats 将分析结果写入文章，摘要仅在为空时填充
+func applyTextStats(article *models.Article) {
+	stats := analyzeContent(article.Title, article.Content)
+	if strings.TrimSpace(article.Excerpt) == "" {
+		article.Excerpt = stats.Excerpt
+	}
+	article.WordCount = stats.WordCount
+	article.ReadingTime = stats.ReadingTime
+	article.Keywords = strings.Join(stats.Keywords, ",")
+}
+
+// applyTextStatsToUpdates 正文或标题变更时重新计算统计信息；
+// 未显式提供摘要且原摘要为空或为自动生成时，一并刷新摘要
+func applyTextStatsToUpdates(existing *models.Article, updates map[string]interface{}) {
+	content, contentChanged := updates["content"].(string)
+	title, titleChanged := updates["title"].(string)
+	if !contentChanged && !titleChanged {
+		return
+	}
+	if !contentChanged {
+		content = existing.Content
+	}
+	if !titleChanged {
+		title = existing.Title
+	}
+
+	stats := analyzeContent(title, content)
+	updates["word_count"] = stats.WordCount
+	updates["reading_time"] = stats.ReadingTime
+	updates["keywords"] = strings.Join(stats.Keywords, ",")
+
+	if excerpt, ok := updates["excerpt"].(string); ok && strings.TrimSpace(excerpt) != "" {
+		return
+	}
+	previousAuto := analyzeContent(existing.Title, existing.Content).Excerpt
+	if strings.TrimSpace(existing.Excerpt) == "" || existing.Excerpt == previousAuto {
+		updates["excerpt"] = stats.Excerpt
+	}
+}
+
+// suggestTags 根据关键词和标题从已有标签中挑选建议标签（排除已选标签）
+func suggestTags(article *models.Article, tags []models.Tag, limit int) []models.Tag {
+	selected := map[string]bool{}
+	for _, tag := range article.Tags {
+		selected[tag.ID] = true
+	}
+
+	keywords := map[string]bool{}
+	for _, kw := range strings.Split(article.Keywords, ",") {
+		if kw != "" {
+			keywords[kw] = true
+		}
+	}
+	title := strings.ToLower(article.Title)
+
+	suggestions := []models.Tag{}
+	for _, tag := range tags {
+		if len(suggestions) >= limit {
+			break
+		}
+		name := strings.ToLower(strings.TrimSpace(tag.Name))
+		if name == "" || selected[tag.ID] {
+			continue
+		}
+		if keywords[name] || containsTerm(title, name) {
+			suggestions = append(suggestions, tag)
+		}
+	}
+	return suggestions
+}
+
+// containsTerm 判断文本是否包含某个词；英文词要求完整单词匹配，避免 "go" 命中 "google"
+func containsTerm(text, term string) bool {
+	for _, r := range term {
+		if isCJK(r) {
+			return strings.Contains(text, term)
+		}
+	}
+	for _, token := range strings.FieldsFunc(text, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '+' || r == '#' || r == '.')
+	}) {
+		if token == term {
+			return true
+		}
+	}
+	return false
+}
+
+// attachSuggestedTags 为新建文章附上建议标签
+func (h *Handlers) attachSuggestedTags(article *models.Article) {
+	tags, err := h.repo.GetTags()
+	if err != nil {
+		return
+	}
+	article.SuggestedTags = suggestTags(article, tags, 5)
+}
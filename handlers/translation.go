@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultLocale 未指定语言时文章使用的默认语言
+const defaultLocale = "zh"
+
+// supportedLocales 支持的文章语言
+var supportedLocales = map[string]bool{
+	"zh": true,
+	"en": true,
+}
+
+// normalizeLocale 将 zh-CN、en_US 等语言标签归一为支持的语言代码，不支持时返回空字符串
+func normalizeLocale(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if supportedLocales[tag] {
+		return tag
+	}
+	return ""
+}
+
+// requestLocale 解析请求期望的语言：优先 ?lang=，其次 Accept-Language；
+// ?lang=all 或无法识别时返回空字符串表示不过滤
+func requestLocale(c *gin.Context) string {
+	if lang := c.Query("lang"); lang != "" {
+		return normalizeLocale(lang)
+	}
+
+	// Accept-Language: en-US,en;q=0.9,zh-CN;q=0.8 —— 按顺序取第一个支持的语言
+	for _, part := range strings.Split(c.GetHeader("Accept-Language"), ",") {
+		tag := strings.SplitN(part, ";", 2)[0]
+		if locale := normalizeLocale(tag); locale != "" {
+			return locale
+		}
+	}
+
+	return ""
+}
+
+// GetArticleTranslations 获取文章的其他语言版本，用于前端语言切换
+func (h *Handlers) GetArticleTranslations(c *gin.Context) {
+	id := c.Param("id")
+
+	translations, err := h.repo.GetArticleTranslations(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, translations)
+}
+
+// ownsArticle 判断文章是否属于指定用户
+func (h *Handlers) ownsArticle(userID, articleID string) bool {
+	article, err := h.repo.GetArticleByIDWithoutStatus(articleID)
+	if err != nil {
+		return false
+	}
+	return article.AuthorID != nil && *article.AuthorID == userID
+}
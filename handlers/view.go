@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxUTMLength = 100
+
+// botUserAgentMarkers 常见搜索引擎、社交平台预览抓取器和脚本工具的 User-Agent 特征（小写）
+var botUserAgentMarkers = []string{
+	"bot", "crawler", "spider", "slurp", "crawl",
+	"facebookexternalhit", "embedly", "quora link preview", "whatsapp", "telegram",
+	"bingpreview", "yahoo! slurp", "baiduspider", "yandex", "sogou", "360spider", "bytespider",
+	"headlesschrome", "phantomjs", "lighthouse", "pingdom", "uptimerobot",
+	"curl/", "wget/", "python-requests", "python-urllib", "go-http-client", "java/", "okhttp",
+	"axios/", "node-fetch", "libwww-perl", "httpclient", "scrapy",
+}
+
+// referrerHost 提取来源站点域名（去掉 www. 前缀），站内跳转和无法解析的来源返回空字符串
+func (h *Handlers) referrerHost(referrer string) string {
+	u, err := url.Parse(strings.TrimSpace(referrer))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if site, err := url.Parse(h.siteURL()); err == nil && site.Hostname() != "" &&
+		host == strings.TrimPrefix(strings.ToLower(site.Hostname()), "www.") {
+		return ""
+	}
+	if len(host) > 191 {
+		return ""
+	}
+	return host
+}
+
+// isBotUserAgent 判断请求是否来自爬虫或脚本，空 User-Agent 同样视为机器请求
+func isBotUserAgent(userAgent string) bool {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return true
+	}
+	for _, marker := range botUserAgentMarkers {
+		if strings.Contains(ua, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// anonymizeIP 去掉 IP 的主机部分后再保存：IPv4 保留前 24 位，IPv6 保留前 48 位
+func anonymizeIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// utmValue 规范化 UTM 参数：去掉首尾空白、转小写并截断到列宽
+func utmValue(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for len(value) > maxUTMLength {
+		_, size := utf8.DecodeLastRuneInString(value)
+		value = value[:len(value)-size]
+	}
+	return value
+}
@@ -0,0 +1,513 @@
+package handlers
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+)
+
+const (
+	webmentionTimeout   = 10 * time.Second
+	webmentionMaxBody   = 1 << 20 // 来源页面最多读取 1MB
+	webmentionMaxLinks  = 20      // 每篇文章最多发送的 Webmention 数
+	webmentionUserAgent = "Mozilla/5.0 (compatible; BlogWebmention/1.0)"
+)
+
+var (
+	errMentionSourceUnavailable = errors.New("source could not be fetched")
+	errMentionNoLink            = errors.New("source does not link to target")
+
+	outboundLinkRe = regexp.MustCompile("https?://[^\\s<>()\\[\\]\"'`]+")
+	linkHeaderRe   = regexp.MustCompile(`<([^>]*)>([^,]*)`)
+	linkRelRe      = regexp.MustCompile(`rel="?([^";]*)"?`)
+)
+
+// mentionClient 抓取外部页面的 HTTP 客户端，只允许连接公网地址，防止借 Webmention 探测内网
+var mentionClient = &http.Client{
+	Timeout: webmentionTimeout,
+	Transport: &http.Transport{
+		DialContext:         publicDialContext,
+		TLSHandshakeTimeout: webmentionTimeout,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return errors.New("too many redirects")
+		}
+		return nil
+	},
+}
+
+// nonPublicNets 不可在公网路由的地址段（IANA 特殊用途地址）：本机、私有、共享地址（CGNAT）、链路本地、
+// 文档与基准测试、组播、保留，以及可能嵌入内网 IPv4 地址的 NAT64、6to4 和 Teredo
+var nonPublicNets = func() []*net.IPNet {
+	cidrs := []string{
+		"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16", "172.16.0.0/12",
+		"192.0.0.0/24", "192.0.2.0/24", "192.88.99.0/24", "192.168.0.0/16", "198.18.0.0/15",
+		"198.51.100.0/24", "203.0.113.0/24", "224.0.0.0/4", "240.0.0.0/4",
+		"::/128", "::1/128", "64:ff9b::/96", "64:ff9b:1::/48", "100::/64", "2001::/23", "2001:db8::/32",
+		"2002::/16", "fc00::/7", "fe80::/10", "ff00::/8",
+	}
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, nets[i], _ = net.ParseCIDR(cidr)
+	}
+	return nets
+}()
+
+func isPublicIP(ip net.IP) bool {
+	for _, n := range nonPublicNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// publicDialContext 解析域名后只连接公网 IP，直接拨号解析结果以避免 DNS 重绑定
+func publicDialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: webmentionTimeout}
+	for _, ip := range ips {
+		if isPublicIP(ip.IP) {
+			return dialer.DialContext(ctx, network, net.JoinHostPort(ip.IP.String(), port))
+		}
+	}
+	return nil, fmt.Errorf("refusing to connect to non-public address %s", host)
+}
+
+func mentionGet(rawURL string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", webmentionUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.8,*/*;q=0.5")
+	return mentionClient.Do(req)
+}
+
+// parseMentionURL 校验并解析 http(s) 绝对地址
+func parseMentionURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid URL: %s", rawURL)
+	}
+	return u, nil
+}
+
+// normalizeMentionURL 比较链接时忽略锚点和末尾斜杠
+func normalizeMentionURL(u *url.URL) string {
+	clean := *u
+	clean.Fragment = ""
+	clean.Host = strings.ToLower(clean.Host)
+	return strings.TrimRight(clean.String(), "/")
+}
+
+// mentionTarget 将被提及的地址解析为本站已发布文章，支持分享页 /a/:slug 和 SPA 文章地址
+func (h *Handlers) mentionTarget(target string) (*models.Article, error) {
+	u, err := parseMentionURL(target)
+	if err != nil {
+		return nil, err
+	}
+	// 未配置站点地址时无法判断目标是否属于本站
+	site := h.siteURL()
+	if site == "" {
+		return nil, errors.New("site URL is not configured")
+	}
+	if su, err := url.Parse(site); err != nil || !strings.EqualFold(su.Host, u.Host) {
+		return nil, errors.New("target is not on this site")
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	article, err := h.repo.GetPublishedArticleBySlug(segments[len(segments)-1])
+	if err != nil {
+		return nil, errors.New("target article not found")
+	}
+	return article, nil
+}
+
+// mentionEntry 从来源页面 h-entry 中解析出的提及内容
+type mentionEntry struct {
+	Author    string
+	AuthorURL string
+	Content   string
+}
+
+// fetchMention 抓取来源页面，确认其链接到目标地址并解析 h-entry
+func fetchMention(source, target string) (*mentionEntry, error) {
+	targetURL, err := parseMentionURL(target)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := mentionGet(source)
+	if err != nil {
+		return nil, errMentionSourceUnavailable
+	}
+	defer resp.Body.Close()
+
+	// 来源页面已删除时视为撤销提及
+	if resp.StatusCode == http.StatusGone || resp.StatusCode == http.StatusNotFound {
+		return nil, errMentionNoLink
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errMentionSourceUnavailable
+	}
+
+	body := io.LimitReader(resp.Body, webmentionMaxBody)
+	if !strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		data, err := io.ReadAll(body)
+		if err != nil || !strings.Contains(string(data), target) {
+			return nil, errMentionNoLink
+		}
+		return &mentionEntry{Author: resp.Request.URL.Host, Content: truncateRunes(string(data), 1000)}, nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(body)
+	if err != nil {
+		return nil, errMentionSourceUnavailable
+	}
+
+	want := normalizeMentionURL(targetURL)
+	linked := false
+	doc.Find("a[href], link[href]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		href, _ := s.Attr("href")
+		if u, err := resp.Request.URL.Parse(href); err == nil && normalizeMentionURL(u) == want {
+			linked = true
+		}
+		return !linked
+	})
+	if !linked {
+		return nil, errMentionNoLink
+	}
+
+	return parseHEntry(doc, resp.Request.URL), nil
+}
+
+// parseHEntry 按 microformats2 解析作者和内容，缺失时回退到页面标题和来源域名
+func parseHEntry(doc *goquery.Document, base *url.URL) *mentionEntry {
+	entry := doc.Find(".h-entry").First()
+	if entry.Length() == 0 {
+		entry = doc.Selection
+	}
+
+	mention := &mentionEntry{}
+	if author := entry.Find(".p-author").First(); author.Length() > 0 {
+		mention.Author = strings.TrimSpace(author.Find(".p-name").First().Text())
+		if mention.Author == "" {
+			mention.Author = strings.TrimSpace(author.Text())
+		}
+		href, ok := author.Find(".u-url").First().Attr("href")
+		if !ok {
+			href, _ = author.Attr("href")
+		}
+		if u, err := base.Parse(href); err == nil && href != "" {
+			mention.AuthorURL = u.String()
+		}
+	}
+	if mention.Author == "" {
+		mention.Author = base.Host
+	}
+
+	for _, selector := range []string{".e-content", ".p-content", ".p-summary", ".p-name"} {
+		if text := strings.TrimSpace(entry.Find(selector).First().Text()); text != "" {
+			mention.Content = text
+			break
+		}
+	}
+	if mention.Content == "" {
+		mention.Content = strings.TrimSpace(doc.Find("title").First().Text())
+	}
+	mention.Content = truncateRunes(whitespaceRe.ReplaceAllString(mention.Content, " "), 1000)
+	mention.Author = truncateRunes(mention.Author, 100)
+
+	return mention
+}
+
+// handleMention 校验来源并保存提及：新提及进入待审核队列，来源更新时重新审核，来源不再链接时删除
+func (h *Handlers) handleMention(source, target string, article *models.Article, ip string) error {
+	entry, err := fetchMention(source, target)
+	existing, lookupErr := h.repo.GetMentionBySource(article.ID, source)
+
+	if errors.Is(err, errMentionNoLink) {
+		if lookupErr == nil {
+			h.repo.DeleteComment(existing.ID)
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if lookupErr == nil {
+		return h.repo.UpdateComment(existing.ID, map[string]interface{}{
+			"author":     entry.Author,
+			"author_url": entry.AuthorURL,
+			"content":    entry.Content,
+			"status":     CommentStatusPending,
+			"updated_at": now,
+		})
+	}
+
+	comment := &models.Comment{
+		ID:        uuid.New().String(),
+		ArticleID: article.ID,
+		Author:    entry.Author,
+		AuthorURL: entry.AuthorURL,
+		Content:   entry.Content,
+		Type:      CommentTypeWebmention,
+		SourceURL: source,
+		Status:    CommentStatusPending,
+		IP:        ip,
+		UserAgent: webmentionUserAgent,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := h.repo.CreateComment(comment); err != nil {
+		return err
+	}
+	h.emailCommentPending(comment, article)
+	return nil
+}
+
+// validateMention 校验 source / target 参数并找到被提及的文章
+func (h *Handlers) validateMention(source, target string) (*models.Article, error) {
+	sourceURL, err := parseMentionURL(source)
+	if err != nil {
+		return nil, err
+	}
+	targetURL, err := parseMentionURL(target)
+	if err != nil {
+		return nil, err
+	}
+	if normalizeMentionURL(sourceURL) == normalizeMentionURL(targetURL) {
+		return nil, errors.New("source and target must be different")
+	}
+	return h.mentionTarget(target)
+}
+
+// ReceiveWebmention W3C Webmention 接收端点，校验参数后异步抓取来源页面
+func (h *Handlers) ReceiveWebmention(c *gin.Context) {
+	source := c.PostForm("source")
+	target := c.PostForm("target")
+
+	article, err := h.validateMention(source, target)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ip := c.ClientIP()
+	go func() {
+		if err := h.handleMention(source, target, article, ip); err != nil {
+			log.Printf("[Webmention] %s -> %s: %v", source, target, err)
+		}
+	}()
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Webmention accepted"})
+}
+
+// xmlrpcCall Pingback 的 XML-RPC 请求
+type xmlrpcCall struct {
+	MethodName string `xml:"methodName"`
+	Params     []struct {
+		Value struct {
+			String string `xml:"string"`
+			Raw    string `xml:",chardata"`
+		} `xml:"value"`
+	} `xml:"params>param"`
+}
+
+const xmlrpcResponse = `<?xml version="1.0"?>
+<methodResponse><params><param><value><string>%s</string></value></param></params></methodResponse>`
+
+const xmlrpcFault = `<?xml version="1.0"?>
+<methodResponse><fault><value><struct>
+<member><name>faultCode</name><value><int>%d</int></value></member>
+<member><name>faultString</name><value><string>%s</string></value></member>
+</struct></value></fault></methodResponse>`
+
+// Pingback 错误码
+const (
+	pingbackSourceMissing = 16 // 来源页面不存在
+	pingbackNoLink        = 17 // 来源页面没有链接到目标
+	pingbackTargetMissing = 32 // 目标不存在
+	pingbackServerError   = 0
+)
+
+func pingbackFault(c *gin.Context, code int, message string) {
+	c.Data(http.StatusOK, "text/xml; charset=utf-8", []byte(fmt.Sprintf(xmlrpcFault, code, html.EscapeString(message))))
+}
+
+// ReceivePingback 兼容旧博客系统的 Pingback（XML-RPC pingback.ping），与 Webmention 共用处理流程
+func (h *Handlers) ReceivePingback(c *gin.Context) {
+	var call xmlrpcCall
+	if err := xml.NewDecoder(io.LimitReader(c.Request.Body, 64<<10)).Decode(&call); err != nil ||
+		call.MethodName != "pingback.ping" || len(call.Params) != 2 {
+		pingbackFault(c, pingbackServerError, "Invalid pingback request")
+		return
+	}
+
+	param := func(i int) string {
+		if v := call.Params[i].Value.String; v != "" {
+			return strings.TrimSpace(v)
+		}
+		return strings.TrimSpace(call.Params[i].Value.Raw)
+	}
+	source, target := param(0), param(1)
+
+	article, err := h.validateMention(source, target)
+	if err != nil {
+		pingbackFault(c, pingbackTargetMissing, err.Error())
+		return
+	}
+
+	switch err := h.handleMention(source, target, article, c.ClientIP()); {
+	case errors.Is(err, errMentionNoLink):
+		pingbackFault(c, pingbackNoLink, err.Error())
+	case errors.Is(err, errMentionSourceUnavailable):
+		pingbackFault(c, pingbackSourceMissing, err.Error())
+	case err != nil:
+		pingbackFault(c, pingbackServerError, "Failed to register pingback")
+	default:
+		c.Data(http.StatusOK, "text/xml; charset=utf-8", []byte(fmt.Sprintf(xmlrpcResponse, "Pingback registered")))
+	}
+}
+
+// outboundLinks 提取正文中指向其他站点的链接（忽略代码块和图片）
+func (h *Handlers) outboundLinks(content string) []string {
+	text := markdownCodeFenceRe.ReplaceAllString(content, " ")
+	text = markdownImageRe.ReplaceAllString(text, "")
+
+	ownHost := ""
+	if su, err := url.Parse(h.siteURL()); err == nil {
+		ownHost = strings.ToLower(su.Host)
+	}
+
+	seen := map[string]bool{}
+	links := []string{}
+	for _, match := range outboundLinkRe.FindAllString(text, -1) {
+		link := strings.TrimRight(match, ".,;:!?")
+		u, err := parseMentionURL(link)
+		if err != nil || strings.EqualFold(u.Host, ownHost) || seen[link] {
+			continue
+		}
+		seen[link] = true
+		links = append(links, link)
+		if len(links) >= webmentionMaxLinks {
+			break
+		}
+	}
+	return links
+}
+
+// discoverWebmentionEndpoint 按规范依次从 HTTP Link 头和 HTML 的 <link>/<a rel="webmention"> 中查找接收端点
+func discoverWebmentionEndpoint(target string) (string, error) {
+	resp, err := mentionGet(target)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	base := resp.Request.URL
+
+	for _, header := range resp.Header.Values("Link") {
+		for _, match := range linkHeaderRe.FindAllStringSubmatch(header, -1) {
+			rel := linkRelRe.FindStringSubmatch(match[2])
+			if rel == nil {
+				continue
+			}
+			for _, r := range strings.Fields(rel[1]) {
+				if r == "webmention" {
+					u, err := base.Parse(match[1])
+					if err != nil {
+						return "", err
+					}
+					return u.String(), nil
+				}
+			}
+		}
+	}
+
+	if !strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		return "", nil
+	}
+	doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, webmentionMaxBody))
+	if err != nil {
+		return "", err
+	}
+	href, ok := doc.Find(`link[rel~="webmention"][href], a[rel~="webmention"][href]`).First().Attr("href")
+	if !ok {
+		return "", nil
+	}
+	u, err := base.Parse(href)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// sendWebmentions 文章发布后异步向正文中链接到的页面发送 Webmention
+func (h *Handlers) sendWebmentions(articleID string) {
+	if h.siteURL() == "" {
+		return // 没有对外地址时对方无法回访来源页面
+	}
+
+	go func() {
+		article, err := h.repo.GetArticleByIDWithoutStatus(articleID)
+		if err != nil || article.Status != "published" {
+			return
+		}
+		source := h.absoluteURL("/a/" + articlePath(article))
+
+		for _, target := range h.outboundLinks(article.Content) {
+			endpoint, err := discoverWebmentionEndpoint(target)
+			if err != nil || endpoint == "" {
+				continue
+			}
+			if _, err := parseMentionURL(endpoint); err != nil {
+				continue
+			}
+
+			req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(url.Values{
+				"source": {source},
+				"target": {target},
+			}.Encode()))
+			if err != nil {
+				continue
+			}
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set("User-Agent", webmentionUserAgent)
+
+			resp, err := mentionClient.Do(req)
+			if err != nil {
+				log.Printf("[Webmention] Failed to notify %s: %v", target, err)
+				continue
+			}
+			resp.Body.Close()
+			log.Printf("[Webmention] Sent %s -> %s: %d", source, target, resp.StatusCode)
+		}
+	}()
+}
@@ -0,0 +1,102 @@
+// Package mail 实现邮件通知：可替换的发送器（本地文件、SMTP）、模板渲染、
+// 退订令牌以及防止邮件轰炸的摘要合并发送。
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Message 待发送的邮件
+type Message struct {
+	To      string
+	Subject string
+	Body    string            // 纯文本正文
+	Headers map[string]string // 额外邮件头，如 List-Unsubscribe
+}
+
+// bytes 按 RFC 5322 格式序列化邮件
+func (m *Message) bytes(from string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", m.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@blog>\r\n", uuid.New().String())
+
+	keys := make([]string, 0, len(m.Headers))
+	for k := range m.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&buf, "%s: %s\r\n", k, m.Headers[k])
+	}
+
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	buf.WriteString(m.Body)
+	return buf.Bytes()
+}
+
+// Mailer 邮件发送器
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// FileMailer 将邮件写成 .eml 文件，用于开发环境或未配置 SMTP 时代替真实发送
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+// NewFileMailer 创建本地文件发送器
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{Dir: dir, From: from}
+}
+
+// Send 写入 Dir 目录
+func (m *FileMailer) Send(ctx context.Context, msg *Message) error {
+	if err := os.MkdirAll(m.Dir, 0755); err != nil {
+		return err
+	}
+	filename := fmt.Sprintf("%s_%s.eml", time.Now().Format("20060102150405"), uuid.New().String()[:8])
+	return os.WriteFile(filepath.Join(m.Dir, filename), msg.bytes(m.From), 0644)
+}
+
+// SMTPMailer 通过 SMTP 服务器发送邮件
+type SMTPMailer struct {
+	Addr     string // host:port
+	Username string
+	Password string
+	From     string
+}
+
+// NewSMTPMailer 创建 SMTP 发送器
+func NewSMTPMailer(addr, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{Addr: addr, Username: username, Password: password, From: from}
+}
+
+// Send 发送邮件
+func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		host, _, err := net.SplitHostPort(m.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+	return smtp.SendMail(m.Addr, auth, m.From, []string{msg.To}, msg.bytes(m.From))
+}
@@ -0,0 +1,218 @@
+package mail
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ranp9275-sketch/blog-backend-golang/redislock"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	digestRecipientsKey = "mail:digest:recipients"
+	digestLockKey       = "mail:digest:lock"
+)
+
+// Suppressor 退订名单
+type Suppressor interface {
+	IsEmailUnsubscribed(email string) bool
+}
+
+// Config 邮件通知配置
+type Config struct {
+	Secret         string        // 签发退订令牌的密钥
+	UnsubscribeURL string        // 退订接口的绝对地址
+	SiteName       string        // 邮件落款
+	InstantLimit   int           // 每个收件人在一个摘要周期内最多即时发送的邮件数，超出后合并到摘要；0 表示全部走摘要
+	DigestInterval time.Duration // 摘要发送周期
+}
+
+// Notifier 邮件通知：即时发送，超出频率后合并为定期摘要
+type Notifier struct {
+	mailer     Mailer
+	redis      *redis.Client
+	suppressor Suppressor
+	cfg        Config
+}
+
+// NewNotifier 创建邮件通知器，mailer 为 nil 时不发送任何邮件
+func NewNotifier(mailer Mailer, rdb *redis.Client, suppressor Suppressor, cfg Config) *Notifier {
+	if cfg.DigestInterval <= 0 {
+		cfg.DigestInterval = time.Hour
+	}
+	return &Notifier{mailer: mailer, redis: rdb, suppressor: suppressor, cfg: cfg}
+}
+
+// normalizeEmail 统一邮箱大小写，保证退订令牌和频率计数一致
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// UnsubscribeToken 为收件人签发退订令牌
+func (n *Notifier) UnsubscribeToken(email string) string {
+	mac := hmac.New(sha256.New, []byte(n.cfg.Secret))
+	mac.Write([]byte("unsubscribe|" + normalizeEmail(email)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyUnsubscribeToken 校验退订令牌
+func (n *Notifier) VerifyUnsubscribeToken(email, token string) bool {
+	return hmac.Equal([]byte(n.UnsubscribeToken(email)), []byte(token))
+}
+
+// unsubscribeLink 收件人专属的退订链接
+func (n *Notifier) unsubscribeLink(email string) string {
+	query := url.Values{}
+	query.Set("email", normalizeEmail(email))
+	query.Set("token", n.UnsubscribeToken(email))
+	return n.cfg.UnsubscribeURL + "?" + query.Encode()
+}
+
+func (n *Notifier) send(ctx context.Context, to, subject, body string) error {
+	link := n.unsubscribeLink(to)
+	return n.mailer.Send(ctx, &Message{
+		To:      to,
+		Subject: subject,
+		Body:    body,
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + link + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	})
+}
+
+// Notify 发送一条通知；收件人在本周期内的即时邮件超过上限时放入摘要队列
+func (n *Notifier) Notify(ctx context.Context, notice Notice) error {
+	notice.To = normalizeEmail(notice.To)
+	if n.mailer == nil || notice.To == "" {
+		return nil
+	}
+	if n.suppressor != nil && n.suppressor.IsEmailUnsubscribed(notice.To) {
+		return nil
+	}
+
+	if n.shouldDigest(ctx, notice.To) {
+		data, err := json.Marshal(notice)
+		if err != nil {
+			return err
+		}
+		pipe := n.redis.TxPipeline()
+		pipe.RPush(ctx, digestQueueKey(notice.To), data)
+		pipe.SAdd(ctx, digestRecipientsKey, notice.To)
+		if _, err := pipe.Exec(ctx); err == nil {
+			return nil
+		}
+		// 入队失败时直接发送，不丢通知
+	}
+
+	subject, body, err := renderNotice(templateData{
+		Notice:         notice,
+		SiteName:       n.cfg.SiteName,
+		UnsubscribeURL: n.unsubscribeLink(notice.To),
+	})
+	if err != nil {
+		return err
+	}
+	return n.send(ctx, notice.To, subject, body)
+}
+
+func digestQueueKey(email string) string {
+	return "mail:digest:queue:" + email
+}
+
+// shouldDigest 按收件人计数，周期内超过即时上限的通知进入摘要
+func (n *Notifier) shouldDigest(ctx context.Context, email string) bool {
+	key := "mail:sent:" + email
+	count, err := n.redis.Incr(ctx, key).Result()
+	if err != nil {
+		return false
+	}
+	if count == 1 {
+		n.redis.Expire(ctx, key, n.cfg.DigestInterval)
+	}
+	return count > int64(n.cfg.InstantLimit)
+}
+
+// RunDigest 按周期发送摘要邮件，阻塞直到 ctx 取消
+func (n *Notifier) RunDigest(ctx context.Context) {
+	ticker := time.NewTicker(n.cfg.DigestInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := n.SendDigests(ctx); err != nil {
+				log.Printf("[Mail] Failed to send digests: %v", err)
+			}
+		}
+	}
+}
+
+// SendDigests 为每个有待发通知的收件人发送一封摘要邮件
+func (n *Notifier) SendDigests(ctx context.Context) error {
+	if n.mailer == nil {
+		return nil
+	}
+
+	// 多实例部署时只允许一个实例发送
+	lock, err := redislock.Acquire(ctx, n.redis, digestLockKey, n.cfg.DigestInterval/2)
+	if err != nil || lock == nil {
+		return err
+	}
+	defer lock.Release()
+
+	recipients, err := n.redis.SMembers(ctx, digestRecipientsKey).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, email := range recipients {
+		var entries *redis.StringSliceCmd
+		_, err := n.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			entries = pipe.LRange(ctx, digestQueueKey(email), 0, -1)
+			pipe.Del(ctx, digestQueueKey(email))
+			pipe.SRem(ctx, digestRecipientsKey, email)
+			return nil
+		})
+		if err != nil {
+			log.Printf("[Mail] Failed to read digest queue for %s: %v", email, err)
+			continue
+		}
+		if n.suppressor != nil && n.suppressor.IsEmailUnsubscribed(email) {
+			continue
+		}
+
+		notices := make([]Notice, 0, len(entries.Val()))
+		for _, entry := range entries.Val() {
+			var notice Notice
+			if err := json.Unmarshal([]byte(entry), &notice); err == nil {
+				notices = append(notices, notice)
+			}
+		}
+		if len(notices) == 0 {
+			continue
+		}
+
+		subject, body, err := renderDigest(digestData{
+			Name:           notices[len(notices)-1].Name,
+			SiteName:       n.cfg.SiteName,
+			UnsubscribeURL: n.unsubscribeLink(email),
+		}, notices)
+		if err != nil {
+			log.Printf("[Mail] Failed to render digest for %s: %v", email, err)
+			continue
+		}
+		if err := n.send(ctx, email, subject, body); err != nil {
+			log.Printf("[Mail] Failed to send digest to %s: %v", email, err)
+		}
+	}
+	return nil
+}
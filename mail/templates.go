@@ -0,0 +1,158 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// 通知类型
+const (
+	KindCommentApproved = "comment_approved" // 评论审核通过，发给评论者
+	KindCommentReply    = "comment_reply"    // 评论收到回复，发给被回复者
+	KindCommentPending  = "comment_pending"  // 新评论待审核，发给文章作者
+)
+
+// Notice 一条邮件通知
+type Notice struct {
+	Kind         string `json:"kind"`
+	To           string `json:"to"`
+	Name         string `json:"name"` // 收件人称呼
+	ArticleTitle string `json:"article_title"`
+	ArticleURL   string `json:"article_url"`
+	Actor        string `json:"actor"` // 触发者，如回复者昵称
+	Excerpt      string `json:"excerpt"`
+}
+
+type noticeTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+const footer = `
+--
+{{if .SiteName}}{{.SiteName}}
+{{end}}不想再收到邮件通知？退订：{{.UnsubscribeURL}}
+`
+
+func mustNoticeTemplate(name, subject, body string) noticeTemplate {
+	return noticeTemplate{
+		subject: template.Must(template.New(name + "_subject").Parse(subject)),
+		body:    template.Must(template.New(name + "_body").Parse(body + footer)),
+	}
+}
+
+var noticeTemplates = map[string]noticeTemplate{
+	KindCommentApproved: mustNoticeTemplate(KindCommentApproved,
+		`你在《{{.ArticleTitle}}》下的评论已通过审核`,
+		`{{if .Name}}{{.Name}}，{{end}}你好：
+
+你在《{{.ArticleTitle}}》下的评论已通过审核并公开展示：
+
+> {{.Excerpt}}
+
+查看文章：{{.ArticleURL}}
+`),
+	KindCommentReply: mustNoticeTemplate(KindCommentReply,
+		`{{.Actor}} 回复了你在《{{.ArticleTitle}}》下的评论`,
+		`{{if .Name}}{{.Name}}，{{end}}你好：
+
+{{.Actor}} 回复了你在《{{.ArticleTitle}}》下的评论：
+
+> {{.Excerpt}}
+
+查看回复：{{.ArticleURL}}
+`),
+	KindCommentPending: mustNoticeTemplate(KindCommentPending,
+		`《{{.ArticleTitle}}》有新评论等待审核`,
+		`{{if .Name}}{{.Name}}，{{end}}你好：
+
+{{.Actor}} 在你的文章《{{.ArticleTitle}}》下发表了评论，正在等待审核：
+
+> {{.Excerpt}}
+
+查看文章：{{.ArticleURL}}
+`),
+}
+
+var digestTemplate = mustNoticeTemplate("digest",
+	`你有 {{len .Items}} 条新通知`,
+	`{{if .Name}}{{.Name}}，{{end}}你好：
+
+以下是最近的通知汇总：
+{{range .Items}}
+- {{.Subject}}
+  {{if .Excerpt}}> {{.Excerpt}}
+  {{end}}{{.ArticleURL}}
+{{end}}`)
+
+// templateData 渲染模板时使用的数据
+type templateData struct {
+	Notice
+	SiteName       string
+	UnsubscribeURL string
+}
+
+// digestItem 摘要邮件中的一项
+type digestItem struct {
+	Subject    string
+	ArticleURL string
+	Excerpt    string
+}
+
+type digestData struct {
+	Name           string
+	Items          []digestItem
+	SiteName       string
+	UnsubscribeURL string
+}
+
+func execute(t *template.Template, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// renderNotice 渲染单条通知的主题和正文
+func renderNotice(data templateData) (subject, body string, err error) {
+	t, ok := noticeTemplates[data.Kind]
+	if !ok {
+		return "", "", fmt.Errorf("unknown notice kind %q", data.Kind)
+	}
+	if subject, err = execute(t.subject, data); err != nil {
+		return "", "", err
+	}
+	body, err = execute(t.body, data)
+	return strings.TrimSpace(subject), body, err
+}
+
+// renderDigest 将多条通知合并为一封摘要邮件
+func renderDigest(data digestData, notices []Notice) (subject, body string, err error) {
+	for _, notice := range notices {
+		t, ok := noticeTemplates[notice.Kind]
+		if !ok {
+			continue
+		}
+		itemSubject, err := execute(t.subject, templateData{Notice: notice})
+		if err != nil {
+			return "", "", err
+		}
+		data.Items = append(data.Items, digestItem{
+			Subject:    strings.TrimSpace(itemSubject),
+			ArticleURL: notice.ArticleURL,
+			Excerpt:    notice.Excerpt,
+		})
+	}
+	if len(data.Items) == 0 {
+		return "", "", fmt.Errorf("empty digest")
+	}
+
+	if subject, err = execute(digestTemplate.subject, data); err != nil {
+		return "", "", err
+	}
+	body, err = execute(digestTemplate.body, data)
+	return strings.TrimSpace(subject), body, err
+}
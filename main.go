@@ -1,17 +1,25 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/ranp9275-sketch/blog-backend-golang/config"
 	"github.com/ranp9275-sketch/blog-backend-golang/handlers"
+	"github.com/ranp9275-sketch/blog-backend-golang/mail"
 	"github.com/ranp9275-sketch/blog-backend-golang/middleware"
 	"github.com/ranp9275-sketch/blog-backend-golang/models"
 	"github.com/ranp9275-sketch/blog-backend-golang/repository"
+	"github.com/ranp9275-sketch/blog-backend-golang/search"
+	"github.com/ranp9275-sketch/blog-backend-golang/spam"
 )
 
 func main() {
@@ -22,6 +30,9 @@ func main() {
 
 	// 初始化配置
 	cfg := config.LoadConfig()
+	if cfg.SigningSecret == "" {
+		log.Fatal("SIGNING_SECRET is required")
+	}
 
 	// 初始化数据库
 	db, err := config.InitDB(cfg)
@@ -37,8 +48,15 @@ func main() {
 		&models.Article{},
 		&models.Comment{},
 		&models.ArticleView{},
+		&models.ArticleViewRollup{},
+		&models.ArticleViewBreakdown{},
 		&models.Favorite{},
 		&models.DonationQRCode{},
+		&models.ArticleTemplate{},
+		&models.Reaction{},
+		&models.Notification{},
+		&models.EmailUnsubscribe{},
+		&models.Report{},
 	); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
@@ -50,15 +68,146 @@ func main() {
 	// 初始化仓储
 	repo := repository.NewRepository(db, redisClient)
 
+	// 创建文章全文索引（ngram 解析器），失败时搜索退化为 LIKE 匹配
+	if err := repo.EnsureSearchIndex(); err != nil {
+		log.Printf("Failed to create full-text search index, falling back to LIKE search: %v", err)
+	}
+
+	// 定期将 Redis 中的点赞、表情回应持久化到数据库
+	go repo.RunReactionSync(context.Background(), time.Minute)
+
+	// 定期将 Redis 中缓冲的浏览量和浏览记录批量写入数据库
+	go repo.RunViewFlush(context.Background(), 10*time.Second)
+
+	// 每天汇总前一天的浏览数据，并清理超过保留期的原始记录
+	retentionDays, err := strconv.Atoi(cfg.ViewRetentionDays)
+	if err != nil || retentionDays < 0 {
+		log.Fatalf("Invalid VIEW_RETENTION_DAYS: %q", cfg.ViewRetentionDays)
+	}
+	go repo.RunViewRollup(context.Background(), retentionDays)
+
+	// 定期重新计算热门文章排行，事件发生时实时累加
+	go repo.RunTrending(context.Background(), 15*time.Minute)
+
 	// 创建 Gin 路由
-	router := gin.Default()
+	// 不使用 gin 默认的访问日志，它会记录完整的查询串
+	router := gin.New()
+	router.Use(gin.Recovery())
+
+	// 客户端 IP 只采信受信任代理设置的 X-Real-IP；nginx 会在 X-Forwarded-For 中保留客户端自带的值，不能使用
+	var trustedProxies []string
+	for _, proxy := range strings.Split(cfg.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+	router.RemoteIPHeaders = []string{"X-Real-IP"}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	// 添加中间件
 	router.Use(middleware.CORSMiddleware())
 	router.Use(middleware.LoggerMiddleware())
 
+	// 初始化评论反垃圾流水线
+	spamConfig := spam.DefaultConfig()
+	spamConfig.Secret = cfg.SigningSecret
+	for _, word := range strings.Split(cfg.SpamBlocklist, ",") {
+		if word = strings.TrimSpace(word); word != "" {
+			spamConfig.Blocklist = append(spamConfig.Blocklist, word)
+		}
+	}
+	spamFilter := spam.NewFilter(redisClient, spamConfig)
+
+	// 初始化邮件通知
+	var mailer mail.Mailer
+	switch cfg.MailDriver {
+	case "smtp":
+		mailer = mail.NewSMTPMailer(cfg.SMTPAddr, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	case "file":
+		mailer = mail.NewFileMailer(cfg.MailDir, cfg.MailFrom)
+	}
+	mailConfig := mail.Config{
+		Secret:         cfg.SigningSecret,
+		UnsubscribeURL: strings.TrimRight(cfg.SiteURL, "/") + "/api/mail/unsubscribe",
+		SiteName:       cfg.SiteName,
+	}
+	if mailConfig.InstantLimit, err = strconv.Atoi(cfg.MailInstantLimit); err != nil {
+		log.Fatalf("Invalid MAIL_INSTANT_LIMIT: %v", err)
+	}
+	if mailConfig.DigestInterval, err = time.ParseDuration(cfg.MailDigestInterval); err != nil {
+		log.Fatalf("Invalid MAIL_DIGEST_INTERVAL: %v", err)
+	}
+	notifier := mail.NewNotifier(mailer, redisClient, repo, mailConfig)
+	go notifier.RunDigest(context.Background())
+
+	// 打开全文检索索引（Bleve），失败时搜索只使用数据库
+	var searchIndex *search.Index
+	searchIndexCreated := false
+	if cfg.SearchIndexPath != "" {
+		if err := os.MkdirAll(filepath.Dir(cfg.SearchIndexPath), 0755); err != nil {
+			log.Fatalf("Failed to create search index directory: %v", err)
+		}
+		searchIndex, searchIndexCreated, err = search.Open(cfg.SearchIndexPath)
+		if err != nil {
+			log.Printf("Failed to open search index, falling back to database search: %v", err)
+		} else {
+			defer searchIndex.Close()
+		}
+	}
+
+	// 搜索建议：每分钟从数据库和 Redis 重新构建内存前缀树
+	suggester := search.NewSuggester(repo.LoadSuggestions)
+	go suggester.Run(context.Background(), time.Minute)
+
+	// 处理器配置
+	settings := handlers.Settings{
+		SiteURL:        cfg.SiteURL,
+		SiteName:       cfg.SiteName,
+		SPAArticlePath: cfg.SPAArticlePath,
+	}
+	if settings.ReportHide.Threshold, err = strconv.Atoi(cfg.ReportHideThreshold); err != nil || settings.ReportHide.Threshold < 0 {
+		log.Fatalf("Invalid REPORT_HIDE_THRESHOLD: %q", cfg.ReportHideThreshold)
+	}
+	if settings.ReportHide.MinAccountAge, err = time.ParseDuration(cfg.ReportMinAccountAge); err != nil {
+		log.Fatalf("Invalid REPORT_MIN_ACCOUNT_AGE: %v", err)
+	}
+
 	// 初始化处理器
-	h := handlers.NewHandlers(repo)
+	h := handlers.NewHandlers(repo, spamFilter, notifier, searchIndex, suggester, settings)
+
+	// 同步其他实例发出的索引更新
+	go h.RunSearchSync(context.Background())
+
+	// 新建的索引在后台从数据库导入已发布文章
+	if searchIndexCreated {
+		go func() {
+			count, err := h.RebuildSearchIndex()
+			if err != nil {
+				log.Printf("Failed to build search index: %v", err)
+				return
+			}
+			log.Printf("Search index built with %d articles", count)
+		}()
+	}
+
+	// 初始化限流规则（Redis 令牌桶，Redis 不可用时降级为进程内限流）
+	limiter := middleware.NewRateLimiter(redisClient)
+	rateLimit := func(name, spec string) gin.HandlerFunc {
+		rule, err := middleware.ParseRateLimitRule(name, spec)
+		if err != nil {
+			log.Fatalf("Failed to parse rate limit config: %v", err)
+		}
+		return limiter.Limit(rule)
+	}
+	commentLimit := rateLimit("comment", cfg.RateLimitComment)
+	viewLimit := rateLimit("view", cfg.RateLimitView)
+	registerLimit := rateLimit("register", cfg.RateLimitRegister)
+	reactionLimit := rateLimit("reaction", cfg.RateLimitReaction)
+	fetchLimit := rateLimit("fetch", cfg.RateLimitFetch)
+	webmentionLimit := rateLimit("webmention", cfg.RateLimitWebmention)
+	reportLimit := rateLimit("report", cfg.RateLimitReport)
 
 	// 静态文件服务 - 上传的图片
 	router.Static("/uploads", "./uploads")
@@ -68,12 +217,15 @@ func main() {
 	{
 		// 认证相关
 		public.POST("/auth/login", h.Login)
-		public.POST("/auth/register", h.Register)
+		public.POST("/auth/register", registerLimit, h.Register)
 
 		// 文章相关
 		public.GET("/articles", h.GetArticles)
 		public.GET("/articles/search", h.SearchArticles)
-		public.GET("/articles/:id", h.GetArticleByID)
+		public.GET("/search/suggest", h.SuggestSearch)
+		public.GET("/articles/trending", h.GetTrendingArticles)
+		public.GET("/articles/:id", middleware.OptionalAuthMiddleware(), h.GetArticleByID)
+		public.GET("/articles/:id/translations", h.GetArticleTranslations)
 		public.GET("/articles/category/:categoryID", h.GetArticlesByCategory)
 		public.GET("/articles/tag/:tagID", h.GetArticlesByTag)
 
@@ -84,17 +236,38 @@ func main() {
 		public.GET("/tags", h.GetTags)
 
 		// 评论相关
-		public.GET("/articles/:id/comments", h.GetComments)
-		public.POST("/articles/:id/comments", h.CreateComment)
+		public.GET("/articles/:id/comments", middleware.OptionalAuthMiddleware(), h.GetComments)
+		public.GET("/articles/:id/comment-token", h.GetCommentToken)
+		public.POST("/articles/:id/comments", middleware.OptionalAuthMiddleware(), commentLimit, h.CreateComment)
+
+		// 点赞与表情回应（登录用户按账号去重，匿名访客按 IP + UA 指纹去重）
+		public.POST("/articles/:id/like", middleware.OptionalAuthMiddleware(), reactionLimit, h.LikeArticle)
+		public.DELETE("/articles/:id/like", middleware.OptionalAuthMiddleware(), reactionLimit, h.UnlikeArticle)
+		public.POST("/comments/:id/reactions", middleware.OptionalAuthMiddleware(), reactionLimit, h.AddCommentReaction)
+		public.DELETE("/comments/:id/reactions/:kind", middleware.OptionalAuthMiddleware(), reactionLimit, h.RemoveCommentReaction)
 
 		// 浏览量统计
-		public.POST("/articles/:id/view", h.RecordView)
+		public.POST("/articles/:id/view", middleware.OptionalAuthMiddleware(), viewLimit, h.RecordView)
 		public.GET("/articles/:id/stats", h.GetArticleStats)
 
+		// 邮件退订
+		public.GET("/mail/unsubscribe", h.ConfirmUnsubscribeEmail)
+		public.POST("/mail/unsubscribe", h.UnsubscribeEmail)
+
+		// 举报
+		public.POST("/reports", middleware.OptionalAuthMiddleware(), reportLimit, h.CreateReport)
+
+		// Webmention / Pingback 接收端点
+		public.POST("/webmention", webmentionLimit, h.ReceiveWebmention)
+		public.POST("/pingback", webmentionLimit, h.ReceivePingback)
+
 		// 打赏二维码（公开）
 		public.GET("/donation/qrcodes", h.GetDonationQRCodes)
 	}
 
+	// 通知实时推送（SSE），EventSource 无法设置请求头，支持 ?ticket= 传递一次性凭证
+	router.GET("/api/user/notifications/stream", h.StreamAuthMiddleware(), h.StreamNotifications)
+
 	// 需要认证的用户路由
 	user := router.Group("/api")
 	user.Use(middleware.AuthMiddleware())
@@ -112,9 +285,22 @@ func main() {
 		user.POST("/user/articles", h.CreateUserArticle)
 		user.POST("/user/articles/upload", h.UploadArticle)
 		// 爬取文章
-		user.POST("/user/articles/fetch", h.FetchArticleByURL)
+		user.POST("/user/articles/fetch", fetchLimit, h.FetchArticleByURL)
 		user.PUT("/user/articles/:id", h.UpdateUserArticle)
 		user.DELETE("/user/articles/:id", h.DeleteUserArticle)
+		user.GET("/user/stats", h.GetUserStats)
+
+		// 通知中心
+		user.GET("/user/notifications", h.GetNotifications)
+		user.POST("/user/notifications/read", h.MarkNotificationsRead)
+		user.POST("/user/notifications/stream-ticket", h.CreateStreamTicket)
+
+		// 用户评论（发表后限时可编辑、删除）
+		user.PUT("/user/comments/:id", h.UpdateUserComment)
+		user.DELETE("/user/comments/:id", h.DeleteUserComment)
+
+		// 文章模板（创建文章时选用）
+		user.GET("/user/templates", h.GetArticleTemplates)
 
 		// 个人信息管理
 		user.PUT("/user/profile", h.UpdateProfile)
@@ -133,12 +319,19 @@ func main() {
 		protected.GET("/articles", h.GetAllArticlesAdmin)
 		protected.GET("/articles/pending", h.GetPendingArticles)
 		protected.POST("/articles", h.CreateArticle)
+		protected.POST("/articles/bulk", h.BulkArticles)
 		protected.PUT("/articles/:id", h.UpdateArticle)
 		protected.DELETE("/articles/:id", h.DeleteArticle)
 		protected.PATCH("/articles/:id/publish", h.PublishArticle)
 		protected.PATCH("/articles/:id/reject", h.RejectArticle)
 		protected.POST("/articles/upload", h.UploadArticle)
 
+		// 文章模板管理
+		protected.GET("/templates", h.GetArticleTemplates)
+		protected.POST("/templates", h.CreateArticleTemplate)
+		protected.PUT("/templates/:id", h.UpdateArticleTemplate)
+		protected.DELETE("/templates/:id", h.DeleteArticleTemplate)
+
 		// 分类管理
 		protected.POST("/categories", h.CreateCategory)
 		protected.PUT("/categories/:id", h.UpdateCategory)
@@ -150,8 +343,24 @@ func main() {
 		protected.DELETE("/tags/:id", h.DeleteTag)
 
 		// 评论管理
+		protected.GET("/comments", h.GetCommentsAdmin)
+		protected.POST("/comments/bulk", h.BulkModerateComments)
+		protected.PATCH("/comments/:id/approve", h.ApproveComment)
+		protected.PATCH("/comments/:id/reject", h.RejectComment)
+		protected.PATCH("/comments/:id/spam", h.MarkCommentSpam)
 		protected.DELETE("/comments/:id", h.DeleteComment)
 
+		// 数据看板
+		protected.GET("/stats", h.GetStats)
+
+		// 全文检索
+		protected.POST("/search/reindex", h.ReindexSearch)
+
+		// 举报处理
+		protected.GET("/reports", h.GetReports)
+		protected.PATCH("/reports/:id/resolve", h.ResolveReport)
+		protected.PATCH("/reports/:id/dismiss", h.DismissReport)
+
 		// 用户管理
 		protected.GET("/users", h.GetAllUsers)
 		protected.PUT("/users/:id/role", h.UpdateUserRole)
@@ -164,6 +373,9 @@ func main() {
 		protected.DELETE("/donation/qrcodes/:id", h.DeleteDonationQRCode)
 	}
 
+	// 文章分享页（服务端渲染 SEO/Open Graph 元数据后跳转 SPA）
+	router.GET("/a/:slug", h.RenderArticleShell)
+
 	// 健康检查
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
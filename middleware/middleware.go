@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -47,6 +48,43 @@ func LoggerMiddleware() gin.HandlerFunc {
 	}
 }
 
+// parseToken 解析 Authorization 头中的 Bearer Token
+func parseToken(authHeader string) (jwt.MapClaims, error) {
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, errInvalidHeader
+	}
+
+	tokenString := parts[1]
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		secret := os.Getenv("JWT_SECRET")
+		if secret == "" {
+			secret = "your-secret-key"
+		}
+		return []byte(secret), nil
+	})
+
+	if err != nil || !token.Valid {
+		return nil, errInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errInvalidClaims
+	}
+
+	return claims, nil
+}
+
+var (
+	errInvalidHeader = errors.New("Invalid authorization header")
+	errInvalidToken  = errors.New("Invalid token")
+	errInvalidClaims = errors.New("Invalid token claims")
+)
+
 // AuthMiddleware 认证中间件
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -57,41 +95,30 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header"})
+		claims, err := parseToken(authHeader)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			c.Abort()
 			return
 		}
 
-		tokenString := parts[1]
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			secret := os.Getenv("JWT_SECRET")
-			if secret == "" {
-				secret = "your-secret-key"
-			}
-			return []byte(secret), nil
-		})
+		c.Set("userID", claims["sub"])
+		c.Set("userRole", claims["role"])
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
-		}
+		c.Next()
+	}
+}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
-			return
+// OptionalAuthMiddleware 可选认证中间件：携带有效 Token 时设置用户信息，否则按匿名请求放行
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+			if claims, err := parseToken(authHeader); err == nil {
+				c.Set("userID", claims["sub"])
+				c.Set("userRole", claims["role"])
+			}
 		}
 
-		c.Set("userID", claims["sub"])
-		c.Set("userRole", claims["role"])
-
 		c.Next()
 	}
 }
@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimitRule 限流规则：每个客户端在 Period 内最多 Limit 次请求（令牌桶容量为 Limit，匀速补充）
+type RateLimitRule struct {
+	Name   string        // 规则名，用于区分不同路由的计数
+	Limit  int           // 桶容量
+	Period time.Duration // 补满整个桶所需时间
+}
+
+// ParseRateLimitRule 解析 "5/1m"、"30/1h" 形式的规则
+func ParseRateLimitRule(name, spec string) (RateLimitRule, error) {
+	parts := strings.SplitN(strings.TrimSpace(spec), "/", 2)
+	if len(parts) != 2 {
+		return RateLimitRule{}, fmt.Errorf("invalid rate limit %q for %s", spec, name)
+	}
+	limit, err := strconv.Atoi(parts[0])
+	if err != nil || limit <= 0 {
+		return RateLimitRule{}, fmt.Errorf("invalid rate limit %q for %s", spec, name)
+	}
+	period, err := time.ParseDuration(parts[1])
+	if err != nil || period < time.Second {
+		return RateLimitRule{}, fmt.Errorf("invalid rate limit %q for %s", spec, name)
+	}
+	return RateLimitRule{Name: name, Limit: limit, Period: period}, nil
+}
+
+// rate 每毫秒补充的令牌数
+func (r RateLimitRule) rate() float64 {
+	return float64(r.Limit) / float64(r.Period.Milliseconds())
+}
+
+// tokenBucketScript 在 Redis 中原子地执行令牌桶算法
+// 返回 {是否允许, 剩余令牌, 需等待的毫秒数}
+var tokenBucketScript = redis.NewScript(`
+local key = KEYS[1]
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+
+local bucket = redis.call('HMGET', key, 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil then
+  tokens = capacity
+  ts = now
+end
+
+tokens = math.min(capacity, tokens + (now - ts) * rate)
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  wait = math.ceil((1 - tokens) / rate)
+end
+
+redis.call('HSET', key, 'tokens', tokens, 'ts', now)
+redis.call('PEXPIRE', key, ttl)
+return {allowed, math.floor(tokens), wait}
+`)
+
+// bucketState 内存令牌桶
+type bucketState struct {
+	tokens float64
+	ts     time.Time
+	period time.Duration
+}
+
+// RateLimiter 基于 Redis 的令牌桶限流器，Redis 不可用时降级为进程内限流
+type RateLimiter struct {
+	redis *redis.Client
+
+	mu        sync.Mutex
+	buckets   map[string]*bucketState
+	lastSweep time.Time
+}
+
+// NewRateLimiter 创建限流器
+func NewRateLimiter(redisClient *redis.Client) *RateLimiter {
+	return &RateLimiter{
+		redis:     redisClient,
+		buckets:   make(map[string]*bucketState),
+		lastSweep: time.Now(),
+	}
+}
+
+// rateLimitResult 单次限流判定结果
+type rateLimitResult struct {
+	allowed   bool
+	remaining int
+	wait      time.Duration
+}
+
+// take 从桶中取一个令牌
+func (l *RateLimiter) take(ctx context.Context, key string, rule RateLimitRule) rateLimitResult {
+	now := time.Now()
+	ttl := 2 * rule.Period
+	res, err := tokenBucketScript.Run(ctx, l.redis, []string{key},
+		rule.Limit, rule.rate(), now.UnixMilli(), ttl.Milliseconds()).Int64Slice()
+	if err == nil && len(res) == 3 {
+		return rateLimitResult{
+			allowed:   res[0] == 1,
+			remaining: int(res[1]),
+			wait:      time.Duration(res[2]) * time.Millisecond,
+		}
+	}
+
+	return l.takeLocal(key, rule, now)
+}
+
+// takeLocal 进程内令牌桶，作为 Redis 故障时的降级方案
+func (l *RateLimiter) takeLocal(key string, rule RateLimitRule, now time.Time) rateLimitResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// 定期清理已经补满的桶，防止内存无限增长
+	if now.Sub(l.lastSweep) > time.Minute {
+		for k, b := range l.buckets {
+			if now.Sub(b.ts) > 2*b.period {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	capacity := float64(rule.Limit)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucketState{tokens: capacity, ts: now, period: rule.Period}
+		l.buckets[key] = b
+	}
+
+	elapsed := float64(now.Sub(b.ts).Milliseconds())
+	b.tokens = math.Min(capacity, b.tokens+elapsed*rule.rate())
+	b.ts = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return rateLimitResult{allowed: true, remaining: int(b.tokens)}
+	}
+	wait := math.Ceil((1 - b.tokens) / rule.rate())
+	return rateLimitResult{remaining: 0, wait: time.Duration(wait) * time.Millisecond}
+}
+
+// Limit 返回按规则限流的中间件；已登录用户按用户 ID 计数，匿名请求按 IP 计数
+func (l *RateLimiter) Limit(rule RateLimitRule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity := "ip:" + c.ClientIP()
+		if userID, exists := c.Get("userID"); exists {
+			if uid, ok := userID.(string); ok && uid != "" {
+				identity = "user:" + uid
+			}
+		}
+		key := fmt.Sprintf("ratelimit:%s:%s", rule.Name, identity)
+
+		result := l.take(c.Request.Context(), key, rule)
+
+		// 桶补满所需时间
+		missing := float64(rule.Limit - result.remaining)
+		reset := time.Now().Add(time.Duration(missing/rule.rate()) * time.Millisecond)
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(rule.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.remaining))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+
+		if !result.allowed {
+			retryAfter := int(math.Ceil(result.wait.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, please try again later"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -41,48 +41,115 @@ type Tag struct {
 
 // Article 文章模型
 type Article struct {
-	ID          string     `gorm:"primaryKey;size:191" json:"id"`
-	Title       string     `json:"title"`
-	Slug        string     `json:"slug"`
-	Content     string     `gorm:"type:longtext" json:"content"`
-	Excerpt     string     `json:"excerpt"`
-	CoverImage  string     `json:"cover_image"`
-	CategoryID  *string    `gorm:"size:191" json:"category_id"`
-	Category    Category   `gorm:"foreignKey:CategoryID" json:"category"`
-	Status      string     `json:"status"` // draft, published
-	Views       int64      `json:"views"`
-	AuthorID    *string    `gorm:"size:191" json:"author_id"`
-	Author      User       `gorm:"foreignKey:AuthorID" json:"author"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
-	PublishedAt *time.Time `json:"published_at"`
+	ID                 string     `gorm:"primaryKey;size:191" json:"id"`
+	Title              string     `json:"title"`
+	Slug               string     `json:"slug"`
+	Content            string     `gorm:"type:longtext" json:"content"`
+	Excerpt            string     `json:"excerpt"`
+	CoverImage         string     `json:"cover_image"`
+	CategoryID         *string    `gorm:"size:191" json:"category_id"`
+	Category           Category   `gorm:"foreignKey:CategoryID" json:"category"`
+	Status             string     `json:"status"` // draft, published
+	Views              int64      `json:"views"`
+	UniqueViews        int64      `gorm:"default:0" json:"unique_views"`              // 独立访客数（HyperLogLog 估算）
+	Locale             string     `gorm:"size:16;index;default:zh" json:"locale"`     // 语言：zh, en
+	TranslationGroupID *string    `gorm:"size:191;index" json:"translation_group_id"` // 互为译文的文章共享同一分组
+	AuthorID           *string    `gorm:"size:191" json:"author_id"`
+	Author             User       `gorm:"foreignKey:AuthorID" json:"author"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+	PublishedAt        *time.Time `json:"published_at"`
+
+	// 保存时根据正文自动计算
+	WordCount   int    `json:"word_count"`
+	ReadingTime int    `json:"reading_time"`              // 预计阅读时长（分钟）
+	Keywords    string `gorm:"type:text" json:"keywords"` // 逗号分隔的候选关键词
+
+	// SEO 元数据，留空时渲染时回退到标题、摘要和封面
+	MetaTitle       string `json:"meta_title"`
+	MetaDescription string `json:"meta_description"`
+	CanonicalURL    string `json:"canonical_url"`
+	OGImage         string `gorm:"column:og_image" json:"og_image"`
 
 	// 关联
 	Tags     []Tag     `gorm:"many2many:article_tags" json:"tags"`
 	Comments []Comment `gorm:"foreignKey:ArticleID" json:"comments"`
+
+	SuggestedTags   []Tag `gorm:"-" json:"suggested_tags,omitempty"`   // 根据关键词建议的标签，不入库
+	PendingComments int64 `gorm:"-" json:"pending_comments,omitempty"` // 待审核评论数（管理员列表）
+	LikeCount       int64 `gorm:"-" json:"like_count,omitempty"`       // 点赞数，来自 Redis 实时计数
+	Liked           bool  `gorm:"-" json:"liked,omitempty"`            // 当前访客是否已点赞
 }
 
 // Comment 评论模型
 type Comment struct {
-	ID        string    `gorm:"primaryKey;size:191" json:"id"`
-	ArticleID string    `gorm:"size:191" json:"article_id"`
-	Article   Article   `gorm:"foreignKey:ArticleID" json:"article"`
-	Author    string    `json:"author"`
-	Email     string    `json:"email"`
-	Content   string    `json:"content"`
-	Status    string    `json:"status"` // pending, approved, rejected
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          string     `gorm:"primaryKey;size:191" json:"id"`
+	ArticleID   string     `gorm:"size:191" json:"article_id"`
+	Article     Article    `gorm:"foreignKey:ArticleID" json:"article"`
+	Author      string     `json:"author"`
+	Email       string     `json:"-"`                             // 匿名评论者填写的邮箱，未经验证，只在审核接口中返回
+	EmailNotify bool       `gorm:"default:false" json:"-"`        // 匿名评论者是否同意接收审核结果和回复邮件
+	UserID      *string    `gorm:"size:191;index" json:"user_id"` // 登录用户发表的评论关联账号
+	User        *User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Type        string     `gorm:"size:16;default:comment" json:"type"`        // comment, webmention
+	SourceURL   string     `gorm:"size:512;index" json:"source_url,omitempty"` // Webmention 来源页面
+	AuthorURL   string     `json:"author_url,omitempty"`                       // Webmention 作者主页
+	Content     string     `json:"content"`
+	Status      string     `gorm:"size:32;index" json:"status"`     // pending, approved, rejected, spam
+	ParentID    *string    `gorm:"size:191;index" json:"parent_id"` // 回复的评论，顶层评论为空
+	RootID      *string    `gorm:"size:191;index" json:"root_id"`   // 所属顶层评论，用于按楼分页
+	Depth       int        `gorm:"default:0" json:"depth"`          // 嵌套层级，顶层为 0
+	IsAuthor    bool       `gorm:"default:false" json:"is_author"`  // 是否为文章作者的回复
+	IP          string     `gorm:"size:64" json:"-"`                // 提交者 IP，用于反垃圾信誉统计
+	UserAgent   string     `json:"-"`
+	SpamScore   float64    `json:"-"` // 反垃圾流水线总分，只在审核接口中返回
+	SpamReasons string     `gorm:"type:text" json:"-"`
+	SpamTrained string     `gorm:"size:8" json:"-"` // 已作为哪类样本训练过：spam, ham
+	EditedAt    *time.Time `json:"edited_at"`       // 作者最后一次编辑时间
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	Replies     []*Comment       `gorm:"-" json:"replies,omitempty"`      // 嵌套回复，不入库
+	Reactions   map[string]int64 `gorm:"-" json:"reactions,omitempty"`    // 各表情回应数
+	MyReactions []string         `gorm:"-" json:"my_reactions,omitempty"` // 当前访客已做出的回应
 }
 
 // ArticleView 文章浏览记录
 type ArticleView struct {
-	ID        string    `gorm:"primaryKey;size:191" json:"id"`
-	ArticleID string    `gorm:"size:191" json:"article_id"`
-	Article   Article   `gorm:"foreignKey:ArticleID" json:"article"`
-	IP        string    `json:"ip"`
-	UserAgent string    `json:"user_agent"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          string    `gorm:"primaryKey;size:191" json:"id"`
+	ArticleID   string    `gorm:"size:191" json:"article_id"`
+	Article     Article   `gorm:"foreignKey:ArticleID" json:"article"`
+	IP          string    `json:"ip"` // 匿名化后的 IP：IPv4 保留前 24 位，IPv6 保留前 48 位
+	UserAgent   string    `json:"user_agent"`
+	Browser     string    `gorm:"size:32" json:"browser"`
+	OS          string    `gorm:"size:32" json:"os"`
+	Device      string    `gorm:"size:16" json:"device"`          // desktop, mobile, tablet, other
+	Referrer    string    `gorm:"size:191;index" json:"referrer"` // 来源站点域名，直接访问为空
+	UTMSource   string    `gorm:"size:100" json:"utm_source"`
+	UTMMedium   string    `gorm:"size:100" json:"utm_medium"`
+	UTMCampaign string    `gorm:"size:100" json:"utm_campaign"`
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`
+}
+
+// ArticleViewRollup 每篇文章每天的浏览汇总，由夜间任务从 article_views 生成
+type ArticleViewRollup struct {
+	ID          string `gorm:"primaryKey;size:191" json:"id"`
+	ArticleID   string `gorm:"size:191;uniqueIndex:idx_rollup_article_date,priority:1" json:"article_id"`
+	Date        string `gorm:"size:10;uniqueIndex:idx_rollup_article_date,priority:2;index" json:"date"` // YYYY-MM-DD
+	Views       int64  `json:"views"`
+	Uniques     int64  `json:"uniques"` // 当天独立访客数（IP + User-Agent）
+	TopReferrer string `gorm:"size:191" json:"top_referrer"`
+	TopUAFamily string `gorm:"size:32" json:"top_ua_family"` // 当天最多的浏览器
+}
+
+// ArticleViewBreakdown 每篇文章每天按维度（来源、浏览器等）拆分的浏览量
+type ArticleViewBreakdown struct {
+	ID        string `gorm:"primaryKey;size:191" json:"id"`
+	ArticleID string `gorm:"size:191;index:idx_breakdown_article_date,priority:1" json:"article_id"`
+	Date      string `gorm:"size:10;index:idx_breakdown_article_date,priority:2;index:idx_breakdown_date_dimension,priority:1" json:"date"`
+	Dimension string `gorm:"size:32;index:idx_breakdown_date_dimension,priority:2" json:"dimension"` // referrer, ua_family, os, device, utm_*
+	Value     string `gorm:"size:191" json:"value"`
+	Views     int64  `json:"views"`
 }
 
 // Favorite 用户收藏
@@ -94,6 +161,55 @@ type Favorite struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// Reaction 点赞与表情回应，Redis 中的实时数据定期持久化到该表
+type Reaction struct {
+	ID         string    `gorm:"primaryKey;size:191" json:"id"`
+	TargetType string    `gorm:"size:16;uniqueIndex:idx_reaction_actor,priority:1" json:"target_type"` // article, comment
+	TargetID   string    `gorm:"size:191;uniqueIndex:idx_reaction_actor,priority:2" json:"target_id"`
+	Kind       string    `gorm:"size:32;uniqueIndex:idx_reaction_actor,priority:3" json:"kind"` // like, thumbs_up, heart ...
+	Actor      string    `gorm:"size:191;uniqueIndex:idx_reaction_actor,priority:4" json:"-"`   // user:<id> 或匿名访客指纹 anon:<hash>
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Notification 站内通知
+type Notification struct {
+	ID        string     `gorm:"primaryKey;size:191" json:"id"`
+	UserID    string     `gorm:"size:191;index:idx_notifications_user_read,priority:1" json:"user_id"` // 接收者
+	Type      string     `gorm:"size:32" json:"type"`                                                  // comment, reply, article_rejected, favorite
+	ArticleID *string    `gorm:"size:191;index" json:"article_id"`
+	CommentID *string    `gorm:"size:191" json:"comment_id"`
+	ActorID   *string    `gorm:"size:191" json:"actor_id"` // 触发通知的用户，匿名评论为空
+	ActorName string     `json:"actor_name"`
+	Title     string     `json:"title"`
+	Message   string     `gorm:"type:text" json:"message"`
+	ReadAt    *time.Time `gorm:"index:idx_notifications_user_read,priority:2" json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Report 读者举报，同一举报人对同一对象只能举报一次
+type Report struct {
+	ID         string     `gorm:"primaryKey;size:191" json:"id"`
+	TargetType string     `gorm:"size:16;uniqueIndex:idx_report_reporter,priority:1;index:idx_report_target,priority:1" json:"target_type"` // article, comment, user
+	TargetID   string     `gorm:"size:191;uniqueIndex:idx_report_reporter,priority:2;index:idx_report_target,priority:2" json:"target_id"`
+	Reporter   string     `gorm:"size:191;uniqueIndex:idx_report_reporter,priority:3" json:"-"` // user:<id> 或匿名访客 IP 指纹 ip:<hash>
+	ReporterID *string    `gorm:"size:191;index" json:"reporter_id"`                            // 登录举报人，匿名举报为空
+	Reason     string     `gorm:"size:32" json:"reason"`                                        // spam, abuse, harassment, plagiarism, inappropriate, other
+	Detail     string     `gorm:"type:text" json:"detail"`
+	Status     string     `gorm:"size:16;index" json:"status"`          // open, resolved, dismissed
+	HiddenFrom string     `gorm:"size:32" json:"hidden_from,omitempty"` // 达到阈值自动隐藏前对象的状态，驳回举报时恢复
+	ResolvedBy *string    `gorm:"size:191" json:"resolved_by"`          // 处理举报的管理员
+	ResolvedAt *time.Time `json:"resolved_at"`
+	Resolution string     `gorm:"type:text" json:"resolution"` // 处理说明
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// EmailUnsubscribe 退订邮件通知的邮箱
+type EmailUnsubscribe struct {
+	ID        string    `gorm:"primaryKey;size:191" json:"id"`
+	Email     string    `gorm:"size:191;uniqueIndex" json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // DonationQRCode 打赏二维码
 type DonationQRCode struct {
 	ID        string    `gorm:"primaryKey;size:191" json:"id"`
@@ -106,6 +222,20 @@ type DonationQRCode struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// ArticleTemplate 文章模板（周报、访谈等固定格式）
+type ArticleTemplate struct {
+	ID                string    `gorm:"primaryKey;size:191" json:"id"`
+	Name              string    `json:"name"`
+	Description       string    `json:"description"`
+	TitlePattern      string    `json:"title_pattern"`                         // 标题模式，支持 {{date}} {{author}} {{week}} 等变量
+	ContentSkeleton   string    `gorm:"type:longtext" json:"content_skeleton"` // 正文骨架，支持同样的变量
+	DefaultCategoryID *string   `gorm:"size:191" json:"default_category_id"`   // 默认分类
+	DefaultCategory   Category  `gorm:"foreignKey:DefaultCategoryID" json:"default_category"`
+	DefaultTags       []Tag     `gorm:"many2many:article_template_tags" json:"default_tags"` // 默认标签
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
 // BeforeCreate 钩子：自动生成 UUID
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == "" {
@@ -162,3 +292,10 @@ func (d *DonationQRCode) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+func (t *ArticleTemplate) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return nil
+}
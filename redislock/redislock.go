@@ -0,0 +1,90 @@
+// Package redislock 基于 Redis 的互斥锁，多实例部署时保证后台任务同一时间只在一个实例上执行。
+package redislock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 只有持有者（令牌一致）才能续期和释放，避免锁过期后误删其他实例获取的锁
+var (
+	refreshScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// Lock 已获取的锁，持有期间每隔 ttl/3 自动续期，任务耗时超过 ttl 也不会被其他实例抢占
+type Lock struct {
+	rdb   *redis.Client
+	key   string
+	token string
+	ttl   time.Duration
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// Acquire 尝试获取锁，锁已被其他实例持有时返回 nil；获取成功后调用方必须调用 Release。
+// ttl 为持有者异常退出后锁自动失效的时间，必须大于 0
+func Acquire(ctx context.Context, rdb *redis.Client, key string, ttl time.Duration) (*Lock, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(buf)
+
+	ok, err := rdb.SetNX(ctx, key, token, ttl).Result()
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	l := &Lock{rdb: rdb, key: key, token: token, ttl: ttl, stop: make(chan struct{}), done: make(chan struct{})}
+	go l.keepAlive()
+	return l, nil
+}
+
+func (l *Lock) keepAlive() {
+	defer close(l.done)
+	interval := l.ttl / 3
+	if interval <= 0 {
+		interval = l.ttl
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			held, err := refreshScript.Run(context.Background(), l.rdb, []string{l.key}, l.token, l.ttl.Milliseconds()).Int()
+			if err != nil {
+				log.Printf("[Lock] Failed to refresh lock %s: %v", l.key, err)
+				continue
+			}
+			if held == 0 {
+				log.Printf("[Lock] Lost lock %s", l.key)
+				return
+			}
+		}
+	}
+}
+
+// Release 停止续期并释放锁；锁已过期并被其他实例获取时保留对方的锁
+func (l *Lock) Release() {
+	close(l.stop)
+	<-l.done
+	if err := releaseScript.Run(context.Background(), l.rdb, []string{l.key}, l.token).Err(); err != nil {
+		log.Printf("[Lock] Failed to release lock %s: %v", l.key, err)
+	}
+}
@@ -0,0 +1,359 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+)
+
+const (
+	statsDayLayout  = "2006-01-02"
+	statsRankingTTL = 5 * time.Minute
+)
+
+// DashboardTotals 站点总量
+type DashboardTotals struct {
+	Articles         int64            `json:"articles"`
+	ArticlesByStatus map[string]int64 `json:"articles_by_status"`
+	Users            int64            `json:"users"`
+	Comments         int64            `json:"comments"`
+	PendingComments  int64            `json:"pending_comments"`
+	Views            int64            `json:"views"`
+}
+
+// DailyStat 某一天的新增数据
+type DailyStat struct {
+	Date     string `json:"date"`
+	Views    int64  `json:"views"`
+	Comments int64  `json:"comments"`
+	Users    int64  `json:"users"`
+}
+
+// TopArticle 时间段内浏览量最高的文章
+type TopArticle struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Slug  string `json:"slug"`
+	Views int64  `json:"views"`
+}
+
+// BreakdownStat 某个拆分维度取值的浏览量
+type BreakdownStat struct {
+	Value string `json:"value"`
+	Views int64  `json:"views"`
+}
+
+// ReferrerStat 来源站点及其带来的浏览量
+type ReferrerStat struct {
+	Referrer string `json:"referrer"`
+	Views    int64  `json:"views"`
+}
+
+// GetDashboardTotals 统计文章、用户、评论和浏览总量
+func (r *Repository) GetDashboardTotals() (*DashboardTotals, error) {
+	totals := &DashboardTotals{ArticlesByStatus: map[string]int64{}}
+
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	if err := r.db.Model(&models.Article{}).Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		totals.ArticlesByStatus[row.Status] = row.Count
+		totals.Articles += row.Count
+	}
+
+	if err := r.db.Model(&models.User{}).Count(&totals.Users).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(&models.Comment{}).Count(&totals.Comments).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(&models.Comment{}).Where("status = ?", "pending").Count(&totals.PendingComments).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(&models.Article{}).Select("COALESCE(SUM(views), 0)").Scan(&totals.Views).Error; err != nil {
+		return nil, err
+	}
+
+	return totals, nil
+}
+
+// rawViewsStart 原始记录中尚未汇总部分的起点：已汇总的日期从汇总表读取，之后的日期从 article_views 读取
+func (r *Repository) rawViewsStart(from time.Time) time.Time {
+	rolled := r.RolledUpThrough()
+	if rolled == "" {
+		return from
+	}
+	if _, end := dayRange(rolled); end.After(from) {
+		return end
+	}
+	return from
+}
+
+// GetDailyStats 按天统计 [from, to] 内的浏览量、新评论数和新用户数，没有数据的日期补 0；
+// 浏览量已汇总的日期读取汇总表，当天等尚未汇总的日期读取原始记录
+func (r *Repository) GetDailyStats(from, to time.Time) ([]DailyStat, error) {
+	end := to.AddDate(0, 0, 1)
+
+	var days []string
+	stats := map[string]*DailyStat{}
+	for day := from; day.Before(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format(statsDayLayout)
+		days = append(days, date)
+		stats[date] = &DailyStat{Date: date}
+	}
+
+	type dayCount struct {
+		Day   string
+		Count int64
+	}
+
+	rawStart := r.rawViewsStart(from)
+	var rolled []dayCount
+	if rawStart.After(from) {
+		if err := r.db.Model(&models.ArticleViewRollup{}).
+			Select("date AS day, SUM(views) AS count").
+			Where("date >= ? AND date < ?", from.Format(statsDayLayout), rawStart.Format(statsDayLayout)).
+			Group("date").
+			Scan(&rolled).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	for _, row := range rolled {
+		if stat, ok := stats[row.Day]; ok {
+			stat.Views += row.Count
+		}
+	}
+
+	counts := []struct {
+		model interface{}
+		start time.Time
+		set   func(stat *DailyStat, n int64)
+	}{
+		{&models.ArticleView{}, rawStart, func(stat *DailyStat, n int64) { stat.Views += n }},
+		{&models.Comment{}, from, func(stat *DailyStat, n int64) { stat.Comments = n }},
+		{&models.User{}, from, func(stat *DailyStat, n int64) { stat.Users = n }},
+	}
+	for _, count := range counts {
+		if !count.start.Before(end) {
+			continue
+		}
+		var rows []dayCount
+		if err := r.db.Model(count.model).
+			Select("DATE_FORMAT(created_at, '%Y-%m-%d') AS day, COUNT(*) AS count").
+			Where("created_at >= ? AND created_at < ?", count.start, end).
+			Group("day").
+			Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if stat, ok := stats[row.Day]; ok {
+				count.set(stat, row.Count)
+			}
+		}
+	}
+
+	series := make([]DailyStat, len(days))
+	for i, day := range days {
+		series[i] = *stats[day]
+	}
+	return series, nil
+}
+
+// cachedRanking 读取或计算排行类统计，结果缓存几分钟
+func (r *Repository) cachedRanking(key string, dest interface{}, compute func() error) error {
+	ctx := context.Background()
+	if data, err := r.redis.Get(ctx, key).Bytes(); err == nil && json.Unmarshal(data, dest) == nil {
+		return nil
+	}
+	if err := compute(); err != nil {
+		return err
+	}
+	if data, err := json.Marshal(dest); err == nil {
+		r.redis.Set(ctx, key, data, statsRankingTTL)
+	}
+	return nil
+}
+
+// viewCount 按某个键汇总的浏览量
+type viewCount struct {
+	Key   string
+	Views int64
+}
+
+// sumViews 汇总 [from, to) 内按 key 分组的浏览量：已汇总的日期读 rolled 查询，其余读原始记录
+func (r *Repository) sumViews(from, to time.Time, rolled func(fromDay, toDay string) ([]viewCount, error),
+	raw func(start, end time.Time) ([]viewCount, error)) (map[string]int64, error) {
+	totals := map[string]int64{}
+
+	rawStart := r.rawViewsStart(from)
+	if rawStart.After(to) {
+		rawStart = to
+	}
+	if rawStart.After(from) {
+		rows, err := rolled(from.Format(statsDayLayout), rawStart.Format(statsDayLayout))
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			totals[row.Key] += row.Views
+		}
+	}
+	if rawStart.Before(to) {
+		rows, err := raw(rawStart, to)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			totals[row.Key] += row.Views
+		}
+	}
+	return totals, nil
+}
+
+// topKeys 按浏览量从高到低取前 limit 个键
+func topKeys(totals map[string]int64, limit int) []string {
+	keys := make([]string, 0, len(totals))
+	for key := range totals {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if totals[keys[i]] != totals[keys[j]] {
+			return totals[keys[i]] > totals[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys
+}
+
+// GetTopArticlesByViews 统计 [from, to) 内浏览量最高的文章
+func (r *Repository) GetTopArticlesByViews(from, to time.Time, limit int) ([]TopArticle, error) {
+	top := []TopArticle{}
+	key := fmt.Sprintf("stats:top_articles:%d:%d:%d", from.Unix(), to.Unix(), limit)
+	err := r.cachedRanking(key, &top, func() error {
+		totals, err := r.sumViews(from, to, func(fromDay, toDay string) ([]viewCount, error) {
+			var rows []viewCount
+			err := r.db.Model(&models.ArticleViewRollup{}).
+				Select("article_id AS `key`, SUM(views) AS views").
+				Where("date >= ? AND date < ?", fromDay, toDay).
+				Group("article_id").
+				Scan(&rows).Error
+			return rows, err
+		}, func(start, end time.Time) ([]viewCount, error) {
+			var rows []viewCount
+			err := r.db.Model(&models.ArticleView{}).
+				Select("article_id AS `key`, COUNT(*) AS views").
+				Where("created_at >= ? AND created_at < ?", start, end).
+				Group("article_id").
+				Scan(&rows).Error
+			return rows, err
+		})
+		if err != nil {
+			return err
+		}
+
+		// 先按浏览量多取一些，排除已删除的文章后再截断
+		ids := topKeys(totals, limit*2)
+		if len(ids) == 0 {
+			return nil
+		}
+		var articles []models.Article
+		if err := r.db.Select("id", "title", "slug").Where("id IN ?", ids).Find(&articles).Error; err != nil {
+			return err
+		}
+		byID := make(map[string]models.Article, len(articles))
+		for _, article := range articles {
+			byID[article.ID] = article
+		}
+		for _, id := range ids {
+			if article, ok := byID[id]; ok && len(top) < limit {
+				top = append(top, TopArticle{ID: id, Title: article.Title, Slug: article.Slug, Views: totals[id]})
+			}
+		}
+		return nil
+	})
+	return top, err
+}
+
+// GetTopReferrers 统计 [from, to) 内带来浏览最多的来源站点，直接访问不计入
+func (r *Repository) GetTopReferrers(from, to time.Time, limit int) ([]ReferrerStat, error) {
+	top := []ReferrerStat{}
+	key := fmt.Sprintf("stats:top_referrers:%d:%d:%d", from.Unix(), to.Unix(), limit)
+	err := r.cachedRanking(key, &top, func() error {
+		totals, err := r.sumViews(from, to, func(fromDay, toDay string) ([]viewCount, error) {
+			var rows []viewCount
+			err := r.db.Model(&models.ArticleViewBreakdown{}).
+				Select("value AS `key`, SUM(views) AS views").
+				Where("dimension = ? AND date >= ? AND date < ? AND value <> ''", BreakdownReferrer, fromDay, toDay).
+				Group("value").
+				Scan(&rows).Error
+			return rows, err
+		}, func(start, end time.Time) ([]viewCount, error) {
+			var rows []viewCount
+			err := r.db.Model(&models.ArticleView{}).
+				Select("referrer AS `key`, COUNT(*) AS views").
+				Where("created_at >= ? AND created_at < ? AND referrer <> ''", start, end).
+				Group("referrer").
+				Scan(&rows).Error
+			return rows, err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, referrer := range topKeys(totals, limit) {
+			top = append(top, ReferrerStat{Referrer: referrer, Views: totals[referrer]})
+		}
+		return nil
+	})
+	return top, err
+}
+
+// GetViewBreakdown 统计文章 [from, to) 内按维度拆分的浏览量，按浏览量从高到低取前 limit 个取值；
+// 来源维度的空值表示直接访问，其他维度不统计空值
+func (r *Repository) GetViewBreakdown(articleID, dimension string, from, to time.Time, limit int) ([]BreakdownStat, error) {
+	column, ok := breakdownColumns[dimension]
+	if !ok {
+		return nil, fmt.Errorf("unknown breakdown dimension: %s", dimension)
+	}
+
+	totals, err := r.sumViews(from, to, func(fromDay, toDay string) ([]viewCount, error) {
+		var rows []viewCount
+		err := r.db.Model(&models.ArticleViewBreakdown{}).
+			Select("value AS `key`, SUM(views) AS views").
+			Where("article_id = ? AND dimension = ? AND date >= ? AND date < ?", articleID, dimension, fromDay, toDay).
+			Group("value").
+			Scan(&rows).Error
+		return rows, err
+	}, func(start, end time.Time) ([]viewCount, error) {
+		query := r.db.Model(&models.ArticleView{}).
+			Select(column+" AS `key`, COUNT(*) AS views").
+			Where("article_id = ? AND created_at >= ? AND created_at < ?", articleID, start, end)
+		if dimension != BreakdownReferrer {
+			query = query.Where(column + " <> ''")
+		}
+		var rows []viewCount
+		err := query.Group(column).Scan(&rows).Error
+		return rows, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := []BreakdownStat{}
+	for _, value := range topKeys(totals, limit) {
+		stats = append(stats, BreakdownStat{Value: value, Views: totals[value]})
+	}
+	return stats, nil
+}
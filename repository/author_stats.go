@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+)
+
+// AuthorStats 作者自己文章的数据汇总
+type AuthorStats struct {
+	Totals   AuthorTotals        `json:"totals"`
+	Articles []AuthorArticleStat `json:"articles"`
+	Series   []AuthorDailyStat   `json:"series"`
+}
+
+// AuthorTotals 作者全部文章的累计数据
+type AuthorTotals struct {
+	Articles    int64 `json:"articles"`
+	Views       int64 `json:"views"`
+	UniqueViews int64 `json:"unique_views"` // 各文章独立访客数之和，同一访客读过多篇文章时重复计算
+	Comments    int64 `json:"comments"`
+	Favorites   int64 `json:"favorites"`
+	PeriodViews int64 `json:"period_views"` // 所选时间段内的浏览量
+}
+
+// AuthorArticleStat 作者单篇文章的数据
+type AuthorArticleStat struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Slug        string     `json:"slug"`
+	Status      string     `json:"status"`
+	PublishedAt *time.Time `json:"published_at"`
+	Views       int64      `json:"views"`
+	UniqueViews int64      `json:"unique_views"`
+	Comments    int64      `json:"comments"`
+	Favorites   int64      `json:"favorites"`
+	PeriodViews int64      `json:"period_views"`
+}
+
+// AuthorDailyStat 作者文章某一天的新增数据
+type AuthorDailyStat struct {
+	Date      string `json:"date"`
+	Views     int64  `json:"views"`
+	Comments  int64  `json:"comments"`
+	Favorites int64  `json:"favorites"`
+}
+
+// GetAuthorStats 统计作者自己文章的累计数据、各文章数据以及 [from, to] 内按天的浏览量、新评论数和新收藏数；
+// 所有查询都限定在作者名下的文章
+func (r *Repository) GetAuthorStats(authorID string, from, to time.Time) (*AuthorStats, error) {
+	end := to.AddDate(0, 0, 1)
+	stats := &AuthorStats{Articles: []AuthorArticleStat{}, Series: []AuthorDailyStat{}}
+
+	var articles []models.Article
+	if err := r.db.Select("id", "title", "slug", "status", "published_at", "views", "unique_views").
+		Where("author_id = ?", authorID).
+		Find(&articles).Error; err != nil {
+		return nil, err
+	}
+
+	days := map[string]*AuthorDailyStat{}
+	for day := from; day.Before(end); day = day.AddDate(0, 0, 1) {
+		stats.Series = append(stats.Series, AuthorDailyStat{Date: day.Format(statsDayLayout)})
+	}
+	for i := range stats.Series {
+		days[stats.Series[i].Date] = &stats.Series[i]
+	}
+	if len(articles) == 0 {
+		return stats, nil
+	}
+
+	ids := make([]string, len(articles))
+	for i, article := range articles {
+		ids[i] = article.ID
+	}
+	comments, err := r.countByArticle(&models.Comment{}, ids, "status = ?", "approved")
+	if err != nil {
+		return nil, err
+	}
+	favorites, err := r.countByArticle(&models.Favorite{}, ids, "")
+	if err != nil {
+		return nil, err
+	}
+	periodViews, err := r.sumViews(from, end, func(fromDay, toDay string) ([]viewCount, error) {
+		var rows []viewCount
+		err := r.db.Model(&models.ArticleViewRollup{}).
+			Select("article_id AS `key`, SUM(views) AS views").
+			Where("article_id IN ? AND date >= ? AND date < ?", ids, fromDay, toDay).
+			Group("article_id").
+			Scan(&rows).Error
+		return rows, err
+	}, func(start, end time.Time) ([]viewCount, error) {
+		var rows []viewCount
+		err := r.db.Model(&models.ArticleView{}).
+			Select("article_id AS `key`, COUNT(*) AS views").
+			Where("article_id IN ? AND created_at >= ? AND created_at < ?", ids, start, end).
+			Group("article_id").
+			Scan(&rows).Error
+		return rows, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pending := r.PendingViews(ids)
+	for _, article := range articles {
+		stat := AuthorArticleStat{
+			ID:          article.ID,
+			Title:       article.Title,
+			Slug:        article.Slug,
+			Status:      article.Status,
+			PublishedAt: article.PublishedAt,
+			Views:       article.Views + pending[article.ID],
+			UniqueViews: r.UniqueViews(article.ID, article.UniqueViews),
+			Comments:    comments[article.ID],
+			Favorites:   favorites[article.ID],
+			PeriodViews: periodViews[article.ID],
+		}
+		stats.Articles = append(stats.Articles, stat)
+
+		stats.Totals.Articles++
+		stats.Totals.Views += stat.Views
+		stats.Totals.UniqueViews += stat.UniqueViews
+		stats.Totals.Comments += stat.Comments
+		stats.Totals.Favorites += stat.Favorites
+		stats.Totals.PeriodViews += stat.PeriodViews
+	}
+	sort.SliceStable(stats.Articles, func(i, j int) bool {
+		a, b := stats.Articles[i], stats.Articles[j]
+		if a.PeriodViews != b.PeriodViews {
+			return a.PeriodViews > b.PeriodViews
+		}
+		return a.Views > b.Views
+	})
+
+	// 按天的浏览量：已汇总的日期读取汇总表，其余读取原始记录
+	dailyViews, err := r.sumViews(from, end, func(fromDay, toDay string) ([]viewCount, error) {
+		var rows []viewCount
+		err := r.db.Model(&models.ArticleViewRollup{}).
+			Select("date AS `key`, SUM(views) AS views").
+			Where("article_id IN ? AND date >= ? AND date < ?", ids, fromDay, toDay).
+			Group("date").
+			Scan(&rows).Error
+		return rows, err
+	}, func(start, end time.Time) ([]viewCount, error) {
+		return r.countByDay(&models.ArticleView{}, ids, start, end, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+	for date, views := range dailyViews {
+		if day, ok := days[date]; ok {
+			day.Views = views
+		}
+	}
+
+	counts := []struct {
+		model interface{}
+		where string
+		set   func(day *AuthorDailyStat, n int64)
+	}{
+		{&models.Comment{}, "status = 'approved'", func(day *AuthorDailyStat, n int64) { day.Comments = n }},
+		{&models.Favorite{}, "", func(day *AuthorDailyStat, n int64) { day.Favorites = n }},
+	}
+	for _, count := range counts {
+		rows, err := r.countByDay(count.model, ids, from, end, count.where)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if day, ok := days[row.Key]; ok {
+				count.set(day, row.Views)
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+// countByArticle 按文章统计记录数，where 为空时不附加条件
+func (r *Repository) countByArticle(model interface{}, articleIDs []string, where string, args ...interface{}) (map[string]int64, error) {
+	db := r.db.Model(model).Select("article_id AS `key`, COUNT(*) AS views").Where("article_id IN ?", articleIDs)
+	if where != "" {
+		db = db.Where(where, args...)
+	}
+	var rows []viewCount
+	if err := db.Group("article_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Key] = row.Views
+	}
+	return counts, nil
+}
+
+// countByDay 按天统计文章 [start, end) 内新增的记录数，where 为空时不附加条件
+func (r *Repository) countByDay(model interface{}, articleIDs []string, start, end time.Time, where string, args ...interface{}) ([]viewCount, error) {
+	db := r.db.Model(model).
+		Select("DATE_FORMAT(created_at, '%Y-%m-%d') AS `key`, COUNT(*) AS views").
+		Where("article_id IN ? AND created_at >= ? AND created_at < ?", articleIDs, start, end)
+	if where != "" {
+		db = db.Where(where, args...)
+	}
+	var rows []viewCount
+	err := db.Group("`key`").Scan(&rows).Error
+	return rows, err
+}
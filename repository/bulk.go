@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+	"gorm.io/gorm"
+)
+
+// ==================== Bulk Article Operations ====================
+
+// 批量操作类型
+const (
+	BulkActionPublish      = "publish"
+	BulkActionUnpublish    = "unpublish"
+	BulkActionDelete       = "delete"
+	BulkActionMoveCategory = "move_category"
+	BulkActionAddTags      = "add_tags"
+	BulkActionRemoveTags   = "remove_tags"
+)
+
+// BulkArticleParams 批量操作参数
+type BulkArticleParams struct {
+	Action     string
+	CategoryID *string // move_category 使用，nil 表示移出分类
+	TagIDs     []string
+}
+
+// BulkResult 批量操作中单篇文章的结果
+type BulkResult struct {
+	ID      string `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BulkUpdateArticles 在一个事务中对多篇文章执行同一操作；
+// 不存在的文章记为失败并继续，数据库错误则整体回滚
+func (r *Repository) BulkUpdateArticles(ids []string, params BulkArticleParams) ([]BulkResult, error) {
+	results := make([]BulkResult, 0, len(ids))
+	var commentIDs []string // 批量删除的文章下的评论，提交后清理 Redis 中的回应
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		results = results[:0]
+		commentIDs = commentIDs[:0]
+
+		var tags []models.Tag
+		if params.Action == BulkActionAddTags || params.Action == BulkActionRemoveTags {
+			if err := tx.Where("id IN ?", params.TagIDs).Find(&tags).Error; err != nil {
+				return err
+			}
+			if len(tags) == 0 {
+				return fmt.Errorf("no valid tags provided")
+			}
+		}
+		if params.Action == BulkActionMoveCategory && params.CategoryID != nil {
+			if err := tx.Where("id = ?", *params.CategoryID).First(&models.Category{}).Error; err != nil {
+				return fmt.Errorf("category not found")
+			}
+		}
+
+		seen := map[string]bool{}
+		for _, id := range ids {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			var article models.Article
+			if err := tx.Select("id").Where("id = ?", id).First(&article).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					results = append(results, BulkResult{ID: id, Error: "Article not found"})
+					continue
+				}
+				return err
+			}
+
+			if params.Action == BulkActionDelete {
+				articleComments, err := articleCommentIDs(tx, article.ID)
+				if err != nil {
+					return err
+				}
+				commentIDs = append(commentIDs, articleComments...)
+			}
+			if err := applyBulkAction(tx, &article, params, tags); err != nil {
+				return fmt.Errorf("article %s: %w", id, err)
+			}
+			results = append(results, BulkResult{ID: id, Success: true})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 清除缓存
+	ctx := context.Background()
+	for _, result := range results {
+		if result.Success {
+			r.redis.Del(ctx, fmt.Sprintf("article:%s", result.ID))
+			if params.Action == BulkActionDelete {
+				r.clearArticleViews(result.ID)
+				r.clearTargetReactions(ReactionTargetArticle, []string{result.ID})
+			}
+		}
+	}
+	r.clearTargetReactions(ReactionTargetComment, commentIDs)
+
+	return results, nil
+}
+
+// applyBulkAction 对单篇文章执行批量操作
+func applyBulkAction(tx *gorm.DB, article *models.Article, params BulkArticleParams, tags []models.Tag) error {
+	now := time.Now()
+	query := tx.Model(&models.Article{}).Where("id = ?", article.ID)
+
+	switch params.Action {
+	case BulkActionPublish:
+		return query.Updates(map[string]interface{}{
+			"status":       "published",
+			"published_at": now,
+			"updated_at":   now,
+		}).Error
+	case BulkActionUnpublish:
+		return query.Updates(map[string]interface{}{
+			"status":     "draft",
+			"updated_at": now,
+		}).Error
+	case BulkActionDelete:
+		return deleteArticleTx(tx, article.ID)
+	case BulkActionMoveCategory:
+		return query.Updates(map[string]interface{}{
+			"category_id": params.CategoryID,
+			"updated_at":  now,
+		}).Error
+	case BulkActionAddTags:
+		return tx.Model(article).Association("Tags").Append(tags)
+	case BulkActionRemoveTags:
+		return tx.Model(article).Association("Tags").Delete(tags)
+	default:
+		return fmt.Errorf("unsupported action: %s", params.Action)
+	}
+}
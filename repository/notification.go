@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// notificationChannel 用户实时通知的 Redis 发布订阅频道，多实例部署时也能推送到对应连接
+func notificationChannel(userID string) string {
+	return "notifications:" + userID
+}
+
+// StreamTicketTTL 通知推送连接凭证的有效期
+const StreamTicketTTL = time.Minute
+
+// streamTicketKey 通知推送连接凭证 -> 用户 ID
+func streamTicketKey(ticket string) string {
+	return "stream:ticket:" + ticket
+}
+
+// CreateStreamTicket 生成一次性的通知推送连接凭证，EventSource 用它代替放在 URL 中的长期 Token
+func (r *Repository) CreateStreamTicket(userID string) (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	ticket := hex.EncodeToString(buf)
+	if err := r.redis.Set(context.Background(), streamTicketKey(ticket), userID, StreamTicketTTL).Err(); err != nil {
+		return "", err
+	}
+	return ticket, nil
+}
+
+// RedeemStreamTicket 使用凭证并立即作废，返回对应的用户 ID；凭证无效或已过期时返回空字符串
+func (r *Repository) RedeemStreamTicket(ticket string) (string, error) {
+	userID, err := r.redis.GetDel(context.Background(), streamTicketKey(ticket)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return userID, err
+}
+
+// CreateNotifications 保存通知并实时推送给在线用户
+func (r *Repository) CreateNotifications(notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	if err := r.db.Create(&notifications).Error; err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	for i := range notifications {
+		data, err := json.Marshal(notifications[i])
+		if err != nil {
+			continue
+		}
+		if err := r.redis.Publish(ctx, notificationChannel(notifications[i].UserID), data).Err(); err != nil {
+			log.Printf("[Notification] Failed to publish notification %s: %v", notifications[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// GetNotifications 分页获取用户通知，unreadOnly 为 true 时只返回未读通知
+func (r *Repository) GetNotifications(userID string, page, pageSize int, unreadOnly bool) ([]models.Notification, int64, error) {
+	var notifications []models.Notification
+	var total int64
+
+	query := r.db.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := query.Offset(offset).Limit(pageSize).
+		Order("created_at DESC").
+		Find(&notifications).Error
+
+	return notifications, total, err
+}
+
+// CountUnreadNotifications 统计用户未读通知数
+func (r *Repository) CountUnreadNotifications(userID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkNotificationsRead 将通知标记为已读，ids 为空时标记全部；返回实际更新的条数
+func (r *Repository) MarkNotificationsRead(userID string, ids []string) (int64, error) {
+	query := r.db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	result := query.Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// SubscribeNotifications 订阅用户的实时通知，调用方负责关闭
+func (r *Repository) SubscribeNotifications(ctx context.Context, userID string) *redis.PubSub {
+	return r.redis.Subscribe(ctx, notificationChannel(userID))
+}
@@ -0,0 +1,300 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+	"github.com/ranp9275-sketch/blog-backend-golang/redislock"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 点赞 / 表情回应的目标类型
+const (
+	ReactionTargetArticle = "article"
+	ReactionTargetComment = "comment"
+
+	ReactionLike = "like" // 文章点赞
+)
+
+// CommentReactionKinds 评论支持的表情回应
+var CommentReactionKinds = []string{"thumbs_up", "heart", "laugh", "hooray", "confused", "eyes"}
+
+const (
+	reactionPendingKey = "reactions:pending" // 待持久化的操作队列
+	reactionWarmKey    = "reactions:warm"    // 标记 Redis 中已载入数据库中的回应
+	reactionLockKey    = "reactions:sync:lock"
+	reactionBatchSize  = 500
+)
+
+// reactionKey 某个目标某种回应的访客集合
+func reactionKey(targetType, targetID, kind string) string {
+	return fmt.Sprintf("reactions:%s:%s:%s", targetType, targetID, kind)
+}
+
+// clearTargetReactions 目标删除后清除 Redis 中的回应集合；队列中尚未持久化的操作由 SyncReactions 跳过
+func (r *Repository) clearTargetReactions(targetType string, targetIDs []string) {
+	if len(targetIDs) == 0 {
+		return
+	}
+	kinds := CommentReactionKinds
+	if targetType == ReactionTargetArticle {
+		kinds = []string{ReactionLike}
+	}
+
+	ctx := context.Background()
+	pipe := r.redis.Pipeline()
+	for _, id := range targetIDs {
+		for _, kind := range kinds {
+			pipe.Del(ctx, reactionKey(targetType, id, kind))
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("[Reaction] Failed to clear reactions of %s %v: %v", targetType, targetIDs, err)
+	}
+}
+
+// AddReaction 记录一次回应，同一访客重复回应不计数；返回是否为新增
+func (r *Repository) AddReaction(targetType, targetID, kind, actor string) (bool, error) {
+	return r.changeReaction("add", targetType, targetID, kind, actor)
+}
+
+// RemoveReaction 撤销回应；返回是否确实撤销
+func (r *Repository) RemoveReaction(targetType, targetID, kind, actor string) (bool, error) {
+	return r.changeReaction("remove", targetType, targetID, kind, actor)
+}
+
+func (r *Repository) changeReaction(op, targetType, targetID, kind, actor string) (bool, error) {
+	ctx := context.Background()
+	key := reactionKey(targetType, targetID, kind)
+
+	var changed int64
+	var err error
+	if op == "add" {
+		changed, err = r.redis.SAdd(ctx, key, actor).Result()
+	} else {
+		changed, err = r.redis.SRem(ctx, key, actor).Result()
+	}
+	if err != nil || changed == 0 {
+		return false, err
+	}
+
+	entry := strings.Join([]string{op, targetType, targetID, kind, actor}, "|")
+	if err := r.redis.RPush(ctx, reactionPendingKey, entry).Err(); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// CountReactions 批量统计各目标的回应数：targetID -> kind -> 数量，数量为 0 的不返回
+func (r *Repository) CountReactions(targetType string, targetIDs, kinds []string) (map[string]map[string]int64, error) {
+	counts := make(map[string]map[string]int64)
+	if len(targetIDs) == 0 || len(kinds) == 0 {
+		return counts, nil
+	}
+
+	ctx := context.Background()
+	pipe := r.redis.Pipeline()
+	cmds := make(map[string]map[string]*redis.IntCmd, len(targetIDs))
+	for _, id := range targetIDs {
+		cmds[id] = make(map[string]*redis.IntCmd, len(kinds))
+		for _, kind := range kinds {
+			cmds[id][kind] = pipe.SCard(ctx, reactionKey(targetType, id, kind))
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return counts, err
+	}
+
+	for id, byKind := range cmds {
+		for kind, cmd := range byKind {
+			if n := cmd.Val(); n > 0 {
+				if counts[id] == nil {
+					counts[id] = make(map[string]int64)
+				}
+				counts[id][kind] = n
+			}
+		}
+	}
+	return counts, nil
+}
+
+// ActorReactions 批量查询访客对各目标已做出的回应：targetID -> kinds
+func (r *Repository) ActorReactions(targetType string, targetIDs, kinds []string, actor string) (map[string][]string, error) {
+	result := make(map[string][]string)
+	if actor == "" || len(targetIDs) == 0 || len(kinds) == 0 {
+		return result, nil
+	}
+
+	ctx := context.Background()
+	pipe := r.redis.Pipeline()
+	type member struct {
+		id, kind string
+		cmd      *redis.BoolCmd
+	}
+	members := make([]member, 0, len(targetIDs)*len(kinds))
+	for _, id := range targetIDs {
+		for _, kind := range kinds {
+			members = append(members, member{id, kind, pipe.SIsMember(ctx, reactionKey(targetType, id, kind), actor)})
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return result, err
+	}
+
+	for _, m := range members {
+		if m.cmd.Val() {
+			result[m.id] = append(result[m.id], m.kind)
+		}
+	}
+	return result, nil
+}
+
+// GetCommentIDsByArticle 获取文章下已通过审核的评论 ID
+func (r *Repository) GetCommentIDsByArticle(articleID string) ([]string, error) {
+	var ids []string
+	err := r.db.Model(&models.Comment{}).
+		Where("article_id = ? AND status = ?", articleID, "approved").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// RunReactionSync 定期将 Redis 中的回应变更写入数据库，阻塞直到 ctx 取消
+func (r *Repository) RunReactionSync(ctx context.Context, interval time.Duration) {
+	if err := r.WarmReactions(ctx); err != nil {
+		log.Printf("[Reaction] Failed to load reactions into Redis: %v", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Redis 重启后数据丢失时重新载入
+			if err := r.WarmReactions(ctx); err != nil {
+				log.Printf("[Reaction] Failed to load reactions into Redis: %v", err)
+				continue
+			}
+			if err := r.SyncReactions(ctx); err != nil {
+				log.Printf("[Reaction] Failed to persist reactions: %v", err)
+			}
+		}
+	}
+}
+
+// WarmReactions Redis 中没有回应数据时，从数据库全量载入
+func (r *Repository) WarmReactions(ctx context.Context) error {
+	ok, err := r.redis.SetNX(ctx, reactionWarmKey, time.Now().Unix(), 0).Result()
+	if err != nil || !ok {
+		return err
+	}
+
+	var reactions []models.Reaction
+	err = r.db.Select("id", "target_type", "target_id", "kind", "actor").
+		FindInBatches(&reactions, reactionBatchSize, func(tx *gorm.DB, batch int) error {
+			pipe := r.redis.Pipeline()
+			for _, reaction := range reactions {
+				pipe.SAdd(ctx, reactionKey(reaction.TargetType, reaction.TargetID, reaction.Kind), reaction.Actor)
+			}
+			_, err := pipe.Exec(ctx)
+			return err
+		}).Error
+	if err != nil {
+		// 载入失败时清除标记，下次重试
+		r.redis.Del(ctx, reactionWarmKey)
+	}
+	return err
+}
+
+// SyncReactions 按顺序将待持久化的回应变更写入数据库
+func (r *Repository) SyncReactions(ctx context.Context) error {
+	// 多实例部署时只允许一个实例同步，保证操作顺序
+	lock, err := redislock.Acquire(ctx, r.redis, reactionLockKey, time.Minute)
+	if err != nil || lock == nil {
+		return err
+	}
+	defer lock.Release()
+
+	for {
+		entries, err := r.redis.LRange(ctx, reactionPendingKey, 0, reactionBatchSize-1).Result()
+		if err != nil || len(entries) == 0 {
+			return err
+		}
+
+		err = r.db.Transaction(func(tx *gorm.DB) error {
+			exists := map[string]bool{}
+			for _, entry := range entries {
+				if err := applyReactionEntry(tx, entry, exists); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// 写入成功后再出队，失败时下次重试（操作本身是幂等的）
+		if err := r.redis.LTrim(ctx, reactionPendingKey, int64(len(entries)), -1).Err(); err != nil {
+			return err
+		}
+		if len(entries) < reactionBatchSize {
+			return nil
+		}
+	}
+}
+
+// applyReactionEntry 写入一条回应变更，exists 缓存本批次中目标是否存在，已删除目标的回应不再写入
+func applyReactionEntry(tx *gorm.DB, entry string, exists map[string]bool) error {
+	parts := strings.Split(entry, "|")
+	if len(parts) != 5 {
+		log.Printf("[Reaction] Skipping malformed entry %q", entry)
+		return nil
+	}
+	op, targetType, targetID, kind, actor := parts[0], parts[1], parts[2], parts[3], parts[4]
+
+	if op == "remove" {
+		return tx.Where("target_type = ? AND target_id = ? AND kind = ? AND actor = ?", targetType, targetID, kind, actor).
+			Delete(&models.Reaction{}).Error
+	}
+
+	target := targetType + ":" + targetID
+	found, ok := exists[target]
+	if !ok {
+		var model interface{}
+		switch targetType {
+		case ReactionTargetArticle:
+			model = &models.Article{}
+		case ReactionTargetComment:
+			model = &models.Comment{}
+		default:
+			log.Printf("[Reaction] Skipping entry with unknown target %q", entry)
+			return nil
+		}
+		var count int64
+		if err := tx.Model(model).Where("id = ?", targetID).Count(&count).Error; err != nil {
+			return err
+		}
+		found = count > 0
+		exists[target] = found
+	}
+	if !found {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Reaction{
+		ID:         uuid.New().String(),
+		TargetType: targetType,
+		TargetID:   targetID,
+		Kind:       kind,
+		Actor:      actor,
+		CreatedAt:  time.Now(),
+	}).Error
+}
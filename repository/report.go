@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 举报对象类型
+const (
+	ReportTargetArticle = "article"
+	ReportTargetComment = "comment"
+	ReportTargetUser    = "user"
+)
+
+// 举报状态
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+)
+
+// ReportGroup 处理队列中的一项：同一对象的所有举报
+type ReportGroup struct {
+	TargetType     string          `json:"target_type"`
+	TargetID       string          `json:"target_id"`
+	ReportCount    int64           `json:"report_count"`
+	LastReportedAt time.Time       `json:"last_reported_at"`
+	Hidden         bool            `json:"hidden"` // 是否已因举报自动隐藏
+	Reports        []models.Report `gorm:"-" json:"reports"`
+	Target         interface{}     `gorm:"-" json:"target"` // 被举报的文章、评论或用户，已删除时为空
+}
+
+// ReportHidePolicy 举报自动隐藏规则：只有注册满 MinAccountAge 的登录用户计入举报人数，
+// 人数达到 Threshold（大于 0 时生效）后自动隐藏；匿名举报只进入处理队列
+type ReportHidePolicy struct {
+	Threshold     int
+	MinAccountAge time.Duration
+}
+
+// CreateReport 保存举报，同一举报人重复举报时 created 为 false；
+// 对象的未处理举报按 policy 计数达到阈值后自动隐藏，hidden 表示本次举报触发了隐藏
+func (r *Repository) CreateReport(report *models.Report, policy ReportHidePolicy) (created, hidden bool, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(report)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		created = true
+
+		// 用户没有公开状态可切换，只进入处理队列；匿名举报的身份成本太低，不触发自动隐藏
+		if policy.Threshold <= 0 || report.TargetType == ReportTargetUser || report.ReporterID == nil {
+			return nil
+		}
+
+		openReports := tx.Model(&models.Report{}).
+			Where("target_type = ? AND target_id = ? AND status = ?", report.TargetType, report.TargetID, ReportStatusOpen)
+		var open, alreadyHidden int64
+		if err := openReports.Session(&gorm.Session{}).
+			Where("reporter_id IN (?)", tx.Model(&models.User{}).Select("id").Where("created_at <= ?", time.Now().Add(-policy.MinAccountAge))).
+			Count(&open).Error; err != nil {
+			return err
+		}
+		if open < int64(policy.Threshold) {
+			return nil
+		}
+		if err := openReports.Session(&gorm.Session{}).Where("hidden_from <> ''").Count(&alreadyHidden).Error; err != nil {
+			return err
+		}
+		if alreadyHidden > 0 {
+			return nil
+		}
+
+		previous, err := hideReportTargetTx(tx, report.TargetType, report.TargetID)
+		if err != nil || previous == "" {
+			return err
+		}
+		hidden = true
+		report.HiddenFrom = previous
+		return tx.Model(report).Update("hidden_from", previous).Error
+	})
+
+	if hidden && report.TargetType == ReportTargetArticle {
+		r.redis.Del(context.Background(), fmt.Sprintf("article:%s", report.TargetID))
+	}
+	return created, hidden, err
+}
+
+// hideReportTargetTx 将公开的文章或评论改为待审核，返回隐藏前的状态；对象不公开时返回空字符串
+func hideReportTargetTx(tx *gorm.DB, targetType, targetID string) (string, error) {
+	var model interface{}
+	var visible string
+	switch targetType {
+	case ReportTargetArticle:
+		model, visible = &models.Article{}, "published"
+	case ReportTargetComment:
+		model, visible = &models.Comment{}, "approved"
+	default:
+		return "", nil
+	}
+
+	result := tx.Model(model).Where("id = ? AND status = ?", targetID, visible).Updates(map[string]interface{}{
+		"status":     "pending",
+		"updated_at": time.Now(),
+	})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return "", result.Error
+	}
+	return visible, nil
+}
+
+// RestoreReportTarget 驳回举报时恢复被自动隐藏的对象，管理员已手动改过状态的不再恢复
+func (r *Repository) RestoreReportTarget(targetType, targetID, status string) error {
+	updates := map[string]interface{}{
+		"status":     status,
+		"updated_at": time.Now(),
+	}
+	switch targetType {
+	case ReportTargetArticle:
+		r.redis.Del(context.Background(), fmt.Sprintf("article:%s", targetID))
+		return r.db.Model(&models.Article{}).Where("id = ? AND status = ?", targetID, "pending").Updates(updates).Error
+	case ReportTargetComment:
+		return r.db.Model(&models.Comment{}).Where("id = ? AND status = ?", targetID, "pending").Updates(updates).Error
+	}
+	return nil
+}
+
+// GetReportByID 获取单条举报
+func (r *Repository) GetReportByID(id string) (*models.Report, error) {
+	var report models.Report
+	err := r.db.Where("id = ?", id).First(&report).Error
+	return &report, err
+}
+
+// GetOpenReports 获取对象所有未处理的举报
+func (r *Repository) GetOpenReports(targetType, targetID string) ([]models.Report, error) {
+	var reports []models.Report
+	err := r.db.Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, ReportStatusOpen).
+		Order("created_at ASC").
+		Find(&reports).Error
+	return reports, err
+}
+
+// CloseReports 关闭对象所有未处理的举报，返回关闭的条数
+func (r *Repository) CloseReports(targetType, targetID, status, adminID, resolution string) (int64, error) {
+	now := time.Now()
+	result := r.db.Model(&models.Report{}).
+		Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, ReportStatusOpen).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"resolved_by": adminID,
+			"resolved_at": now,
+			"resolution":  resolution,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// GetReportQueue 按被举报对象分组获取举报处理队列，举报人数多的排在前面
+func (r *Repository) GetReportQueue(page, pageSize int, status, targetType string) ([]ReportGroup, int64, error) {
+	query := r.db.Model(&models.Report{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	grouped := query.Select("target_type, target_id, COUNT(*) AS report_count, " +
+		"MAX(created_at) AS last_reported_at, MAX(hidden_from <> '') AS hidden").
+		Group("target_type, target_id")
+
+	var total int64
+	if err := r.db.Table("(?) AS g", grouped.Session(&gorm.Session{})).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var groups []ReportGroup
+	offset := (page - 1) * pageSize
+	if err := grouped.Order("report_count DESC, last_reported_at DESC").
+		Offset(offset).Limit(pageSize).
+		Scan(&groups).Error; err != nil {
+		return nil, 0, err
+	}
+	if len(groups) == 0 {
+		return groups, total, nil
+	}
+
+	// 附上每组的举报明细
+	idsByType := map[string][]string{}
+	targetIDs := make([]string, 0, len(groups))
+	for _, group := range groups {
+		idsByType[group.TargetType] = append(idsByType[group.TargetType], group.TargetID)
+		targetIDs = append(targetIDs, group.TargetID)
+	}
+	reportQuery := r.db.Where("target_id IN ?", targetIDs)
+	if status != "" {
+		reportQuery = reportQuery.Where("status = ?", status)
+	}
+	var reports []models.Report
+	if err := reportQuery.Order("created_at ASC").Find(&reports).Error; err != nil {
+		return nil, 0, err
+	}
+	byTarget := map[string][]models.Report{}
+	for _, report := range reports {
+		key := report.TargetType + ":" + report.TargetID
+		byTarget[key] = append(byTarget[key], report)
+	}
+
+	targets, err := r.getReportTargets(idsByType)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range groups {
+		key := groups[i].TargetType + ":" + groups[i].TargetID
+		groups[i].Reports = byTarget[key]
+		if target, ok := targets[key]; ok {
+			groups[i].Target = target
+		}
+	}
+
+	return groups, total, nil
+}
+
+// getReportTargets 批量加载被举报对象的概要，键为 "类型:ID"
+func (r *Repository) getReportTargets(idsByType map[string][]string) (map[string]interface{}, error) {
+	targets := map[string]interface{}{}
+
+	if ids := idsByType[ReportTargetArticle]; len(ids) > 0 {
+		var articles []models.Article
+		if err := r.db.Select("id", "title", "slug", "status", "author_id", "created_at").
+			Preload("Author", commentUserColumns).
+			Where("id IN ?", ids).Find(&articles).Error; err != nil {
+			return nil, err
+		}
+		for i := range articles {
+			targets[ReportTargetArticle+":"+articles[i].ID] = &articles[i]
+		}
+	}
+
+	if ids := idsByType[ReportTargetComment]; len(ids) > 0 {
+		var comments []models.Comment
+		if err := r.db.Preload("Article", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "title", "slug", "status", "author_id")
+		}).
+			Preload("User", commentUserColumns).
+			Where("id IN ?", ids).Find(&comments).Error; err != nil {
+			return nil, err
+		}
+		for i := range comments {
+			targets[ReportTargetComment+":"+comments[i].ID] = &comments[i]
+		}
+	}
+
+	if ids := idsByType[ReportTargetUser]; len(ids) > 0 {
+		var users []models.User
+		if err := r.db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+			return nil, err
+		}
+		for i := range users {
+			targets[ReportTargetUser+":"+users[i].ID] = &users[i]
+		}
+	}
+
+	return targets, nil
+}
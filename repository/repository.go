@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/ranp9275-sketch/blog-backend-golang/models"
@@ -14,6 +15,8 @@ import (
 type Repository struct {
 	db    *gorm.DB
 	redis *redis.Client
+
+	fulltext atomic.Bool // articles 表是否已有全文索引，见 EnsureSearchIndex
 }
 
 func NewRepository(db *gorm.DB, redis *redis.Client) *Repository {
@@ -37,16 +40,23 @@ func (r *Repository) CreateUser(user *models.User) error {
 
 // ==================== Article ====================
 
-func (r *Repository) GetArticles(page, pageSize int) ([]models.Article, int64, error) {
+func (r *Repository) GetArticles(page, pageSize int, locale string) ([]models.Article, int64, error) {
 	var articles []models.Article
 	var total int64
 
-	r.db.Model(&models.Article{}).Where("status = ?", "published").Count(&total)
+	query := r.db.Model(&models.Article{}).Where("status = ?", "published")
+	if locale != "" {
+		query = query.Where("locale = ?", locale)
+	}
+	query.Count(&total)
 
 	offset := (page - 1) * pageSize
-	err := r.db.Preload("Category").Preload("Author").Preload("Tags").
-		Where("status = ?", "published").
-		Offset(offset).Limit(pageSize).
+	dataQuery := r.db.Preload("Category").Preload("Author").Preload("Tags").
+		Where("status = ?", "published")
+	if locale != "" {
+		dataQuery = dataQuery.Where("locale = ?", locale)
+	}
+	err := dataQuery.Offset(offset).Limit(pageSize).
 		Order("published_at DESC").
 		Find(&articles).Error
 
@@ -97,26 +107,75 @@ func (r *Repository) DeleteArticle(id string) error {
 	r.redis.Del(context.Background(), cacheKey)
 
 	// 使用事务删除文章及其关联数据
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		// 删除文章浏览记录
-		if err := tx.Where("article_id = ?", id).Delete(&models.ArticleView{}).Error; err != nil {
-			return err
-		}
-		// 删除文章评论
-		if err := tx.Where("article_id = ?", id).Delete(&models.Comment{}).Error; err != nil {
-			return err
-		}
-		// 删除文章收藏
-		if err := tx.Where("article_id = ?", id).Delete(&models.Favorite{}).Error; err != nil {
-			return err
-		}
-		// 删除文章标签关联
-		if err := tx.Exec("DELETE FROM article_tags WHERE article_id = ?", id).Error; err != nil {
+	var commentIDs []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if commentIDs, err = articleCommentIDs(tx, id); err != nil {
 			return err
 		}
-		// 删除文章
-		return tx.Delete(&models.Article{}, "id = ?", id).Error
+		return deleteArticleTx(tx, id)
 	})
+	if err == nil {
+		r.clearArticleViews(id)
+		r.clearTargetReactions(ReactionTargetArticle, []string{id})
+		r.clearTargetReactions(ReactionTargetComment, commentIDs)
+	}
+	return err
+}
+
+// articleCommentIDs 文章下全部评论的 ID，删除文章前取出，用于事务提交后清理 Redis 中的回应
+func articleCommentIDs(tx *gorm.DB, articleID string) ([]string, error) {
+	var ids []string
+	err := tx.Model(&models.Comment{}).Where("article_id = ?", articleID).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// deleteArticleTx 在事务中删除文章及其关联数据
+func deleteArticleTx(tx *gorm.DB, id string) error {
+	// 删除文章浏览记录
+	if err := tx.Where("article_id = ?", id).Delete(&models.ArticleView{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("article_id = ?", id).Delete(&models.ArticleViewRollup{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("article_id = ?", id).Delete(&models.ArticleViewBreakdown{}).Error; err != nil {
+		return err
+	}
+	// 删除与文章相关的通知
+	if err := tx.Where("article_id = ?", id).Delete(&models.Notification{}).Error; err != nil {
+		return err
+	}
+	// 删除文章及其评论的点赞、表情回应
+	if err := tx.Where("target_type = ? AND target_id = ?", ReactionTargetArticle, id).Delete(&models.Reaction{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("target_type = ? AND target_id IN (?)", ReactionTargetComment,
+		tx.Model(&models.Comment{}).Select("id").Where("article_id = ?", id)).Delete(&models.Reaction{}).Error; err != nil {
+		return err
+	}
+	// 删除针对文章及其评论的举报
+	if err := tx.Where("target_type = ? AND target_id = ?", ReportTargetArticle, id).Delete(&models.Report{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("target_type = ? AND target_id IN (?)", ReportTargetComment,
+		tx.Model(&models.Comment{}).Select("id").Where("article_id = ?", id)).Delete(&models.Report{}).Error; err != nil {
+		return err
+	}
+	// 删除文章评论
+	if err := tx.Where("article_id = ?", id).Delete(&models.Comment{}).Error; err != nil {
+		return err
+	}
+	// 删除文章收藏
+	if err := tx.Where("article_id = ?", id).Delete(&models.Favorite{}).Error; err != nil {
+		return err
+	}
+	// 删除文章标签关联
+	if err := tx.Exec("DELETE FROM article_tags WHERE article_id = ?", id).Error; err != nil {
+		return err
+	}
+	// 删除文章
+	return tx.Delete(&models.Article{}, "id = ?", id).Error
 }
 
 func (r *Repository) GetArticlesByCategory(categoryID string, page, pageSize int) ([]models.Article, int64, error) {
@@ -211,62 +270,222 @@ func (r *Repository) GetCommentsByArticleID(articleID string) ([]models.Comment,
 	return comments, err
 }
 
+// GetCommentThreads 按顶层评论分页获取已通过的评论，并将回复组装为嵌套树
+func (r *Repository) GetCommentThreads(articleID string, page, pageSize int) ([]*models.Comment, int64, error) {
+	var roots []*models.Comment
+	var total int64
+
+	query := r.db.Model(&models.Comment{}).
+		Where("article_id = ? AND status = ? AND parent_id IS NULL", articleID, "approved")
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	if err := query.Preload("User", commentUserColumns).
+		Offset(offset).Limit(pageSize).
+		Order("created_at DESC").
+		Find(&roots).Error; err != nil {
+		return nil, 0, err
+	}
+	if len(roots) == 0 {
+		return []*models.Comment{}, total, nil
+	}
+
+	rootIDs := make([]string, 0, len(roots))
+	nodes := make(map[string]*models.Comment, len(roots))
+	for _, root := range roots {
+		rootIDs = append(rootIDs, root.ID)
+		nodes[root.ID] = root
+	}
+
+	var replies []*models.Comment
+	if err := r.db.Preload("User", commentUserColumns).
+		Where("root_id IN ? AND status = ?", rootIDs, "approved").
+		Order("depth ASC, created_at ASC").
+		Find(&replies).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 按层级顺序挂载，父评论未通过审核时其回复不展示
+	for _, reply := range replies {
+		nodes[reply.ID] = reply
+	}
+	for _, reply := range replies {
+		if reply.ParentID == nil {
+			continue
+		}
+		if parent, ok := nodes[*reply.ParentID]; ok {
+			parent.Replies = append(parent.Replies, reply)
+		}
+	}
+
+	return roots, total, nil
+}
+
+// commentUserColumns 评论关联用户只加载公开信息
+func commentUserColumns(db *gorm.DB) *gorm.DB {
+	return db.Select("id", "name", "avatar")
+}
+
+func (r *Repository) GetCommentByID(id string) (*models.Comment, error) {
+	var comment models.Comment
+	err := r.db.Where("id = ?", id).First(&comment).Error
+	return &comment, err
+}
+
+func (r *Repository) GetCommentsByIDs(ids []string) ([]models.Comment, error) {
+	var comments []models.Comment
+	err := r.db.Where("id IN ?", ids).Find(&comments).Error
+	return comments, err
+}
+
+func (r *Repository) UpdateComment(id string, updates map[string]interface{}) error {
+	return r.db.Model(&models.Comment{}).Where("id = ?", id).Updates(updates).Error
+}
+
 func (r *Repository) CreateComment(comment *models.Comment) error {
 	return r.db.Create(comment).Error
 }
 
 func (r *Repository) DeleteComment(id string) error {
-	return r.db.Delete(&models.Comment{}, "id = ?", id).Error
+	_, err := r.DeleteComments([]string{id})
+	return err
+}
+
+// DeleteComments 删除评论及其所有回复
+func (r *Repository) DeleteComments(ids []string) (int64, error) {
+	var affected int64
+	var allIDs []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		allIDs = append([]string{}, ids...)
+		parentIDs := ids
+		for len(parentIDs) > 0 {
+			var childIDs []string
+			if err := tx.Model(&models.Comment{}).Where("parent_id IN ?", parentIDs).Pluck("id", &childIDs).Error; err != nil {
+				return err
+			}
+			allIDs = append(allIDs, childIDs...)
+			parentIDs = childIDs
+		}
+
+		if err := tx.Where("target_type = ? AND target_id IN ?", ReactionTargetComment, allIDs).Delete(&models.Reaction{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("target_type = ? AND target_id IN ?", ReportTargetComment, allIDs).Delete(&models.Report{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where("id IN ?", allIDs).Delete(&models.Comment{})
+		affected = result.RowsAffected
+		return result.Error
+	})
+	if err == nil {
+		r.clearTargetReactions(ReactionTargetComment, allIDs)
+	}
+	return affected, err
 }
 
-// ==================== Stats ====================
+// GetCommentsForModeration 管理员按状态获取评论，附带文章标题等上下文
+func (r *Repository) GetCommentsForModeration(page, pageSize int, status, articleID string) ([]models.Comment, int64, error) {
+	var comments []models.Comment
+	var total int64
+
+	query := r.db.Model(&models.Comment{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if articleID != "" {
+		query = query.Where("article_id = ?", articleID)
+	}
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := query.Preload("Article", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "title", "slug", "status", "author_id")
+	}).
+		Preload("User", commentUserColumns).
+		Offset(offset).Limit(pageSize).
+		Order("created_at DESC").
+		Find(&comments).Error
 
-func (r *Repository) RecordView(view *models.ArticleView) error {
-	return r.db.Create(view).Error
+	return comments, total, err
 }
 
-func (r *Repository) IncrementArticleViews(articleID string) error {
-	return r.db.Model(&models.Article{}).Where("id = ?", articleID).
-		UpdateColumn("views", gorm.Expr("views + ?", 1)).Error
+// UpdateCommentsStatus 批量更新评论状态，返回实际更新的条数
+func (r *Repository) UpdateCommentsStatus(ids []string, status string) (int64, error) {
+	result := r.db.Model(&models.Comment{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"status":     status,
+		"updated_at": time.Now(),
+	})
+	return result.RowsAffected, result.Error
 }
 
-func (r *Repository) GetArticleStats(articleID string) (int64, int64, error) {
-	var views int64
-	var comments int64
+// CountPendingCommentsByArticle 统计每篇文章的待审核评论数
+func (r *Repository) CountPendingCommentsByArticle(articleIDs []string) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	if len(articleIDs) == 0 {
+		return counts, nil
+	}
 
-	// 获取浏览量
-	var article models.Article
-	if err := r.db.Select("views").Where("id = ?", articleID).First(&article).Error; err != nil {
-		return 0, 0, err
+	var rows []struct {
+		ArticleID string
+		Count     int64
+	}
+	err := r.db.Model(&models.Comment{}).
+		Select("article_id, COUNT(*) AS count").
+		Where("article_id IN ? AND status = ?", articleIDs, "pending").
+		Group("article_id").
+		Scan(&rows).Error
+	for _, row := range rows {
+		counts[row.ArticleID] = row.Count
 	}
-	views = article.Views
 
-	// 获取评论数
-	r.db.Model(&models.Comment{}).Where("article_id = ? AND status = ?", articleID, "approved").Count(&comments)
+	return counts, err
+}
 
-	return views, comments, nil
+// GetMentionBySource 获取文章下来自某个页面的 Webmention
+func (r *Repository) GetMentionBySource(articleID, sourceURL string) (*models.Comment, error) {
+	var comment models.Comment
+	err := r.db.Where("article_id = ? AND type = ? AND source_url = ?", articleID, "webmention", sourceURL).
+		First(&comment).Error
+	return &comment, err
 }
 
-// ==================== Search ====================
+// CountApprovedCommentsByUser 统计用户已通过审核的评论数，用于判断是否为可信用户
+func (r *Repository) CountApprovedCommentsByUser(userID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Comment{}).
+		Where("user_id = ? AND status = ?", userID, "approved").
+		Count(&count).Error
+	return count, err
+}
 
-func (r *Repository) SearchArticles(query string, page, pageSize int) ([]models.Article, int64, error) {
-	var articles []models.Article
-	var total int64
+// CountCommentReplies 统计评论的直接回复数
+func (r *Repository) CountCommentReplies(id string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Comment{}).Where("parent_id = ?", id).Count(&count).Error
+	return count, err
+}
 
-	searchQuery := "%" + query + "%"
+// ==================== Stats ====================
 
-	r.db.Model(&models.Article{}).
-		Where("status = ? AND (title LIKE ? OR content LIKE ? OR excerpt LIKE ?)", "published", searchQuery, searchQuery, searchQuery).
-		Count(&total)
+// GetArticleStats 获取文章总浏览量、独立访客数和已公开评论数
+func (r *Repository) GetArticleStats(articleID string) (int64, int64, int64, error) {
+	var views int64
+	var comments int64
 
-	offset := (page - 1) * pageSize
-	err := r.db.Preload("Category").Preload("Author").Preload("Tags").
-		Where("status = ? AND (title LIKE ? OR content LIKE ? OR excerpt LIKE ?)", "published", searchQuery, searchQuery, searchQuery).
-		Offset(offset).Limit(pageSize).
-		Order("published_at DESC").
-		Find(&articles).Error
+	// 获取浏览量
+	var article models.Article
+	if err := r.db.Select("views", "unique_views").Where("id = ?", articleID).First(&article).Error; err != nil {
+		return 0, 0, 0, err
+	}
+	// 加上尚未写入数据库的浏览量
+	views = article.Views + r.PendingViews([]string{articleID})[articleID]
+	uniqueViews := r.UniqueViews(articleID, article.UniqueViews)
 
-	return articles, total, err
+	// 获取评论数
+	r.db.Model(&models.Comment{}).Where("article_id = ? AND status = ?", articleID, "approved").Count(&comments)
+
+	return views, uniqueViews, comments, nil
 }
 
 // ==================== User ====================
@@ -277,6 +496,15 @@ func (r *Repository) GetUserByID(id string) (*models.User, error) {
 	return &user, err
 }
 
+func (r *Repository) GetUsersByIDs(ids []string) ([]models.User, error) {
+	var users []models.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
 // ==================== Favorite ====================
 
 func (r *Repository) GetFavorites(userID string) ([]models.Article, error) {
@@ -303,14 +531,19 @@ func (r *Repository) GetFavorites(userID string) ([]models.Article, error) {
 	return articles, err
 }
 
-func (r *Repository) AddFavorite(favorite *models.Favorite) error {
+// AddFavorite 添加收藏，返回是否为新收藏；已收藏时不重复添加
+func (r *Repository) AddFavorite(favorite *models.Favorite) (bool, error) {
 	// 检查是否已收藏
 	var existing models.Favorite
 	err := r.db.Where("user_id = ? AND article_id = ?", favorite.UserID, favorite.ArticleID).First(&existing).Error
 	if err == nil {
-		return nil // 已存在，不重复添加
+		return false, nil // 已存在，不重复添加
 	}
-	return r.db.Create(favorite).Error
+	if err := r.db.Create(favorite).Error; err != nil {
+		return false, err
+	}
+	r.TrackTrending(favorite.ArticleID, TrendingWeightFavorite)
+	return true, nil
 }
 
 func (r *Repository) RemoveFavorite(userID, articleID string) error {
@@ -333,6 +566,15 @@ func (r *Repository) GetArticleByIDWithoutStatus(id string) (*models.Article, er
 	return &article, err
 }
 
+// GetPublishedArticleBySlug 通过 slug（或 ID）获取已发布文章
+func (r *Repository) GetPublishedArticleBySlug(slug string) (*models.Article, error) {
+	var article models.Article
+	err := r.db.Preload("Category").Preload("Author").Preload("Tags").
+		Where("(slug = ? OR id = ?) AND status = ?", slug, slug, "published").
+		First(&article).Error
+	return &article, err
+}
+
 func (r *Repository) GetArticlesByAuthor(authorID string, page, pageSize int, query, status, categoryID, tagID string) ([]models.Article, int64, error) {
 	var articles []models.Article
 	var total int64
@@ -409,15 +651,34 @@ func (r *Repository) GetAllArticles(page, pageSize int, status string) ([]models
 	err := dataQuery.Offset(offset).Limit(pageSize).
 		Order("created_at DESC").
 		Find(&articles).Error
+	if err != nil {
+		return articles, total, err
+	}
+
+	// 附带每篇文章的待审核评论数
+	articleIDs := make([]string, 0, len(articles))
+	for _, a := range articles {
+		articleIDs = append(articleIDs, a.ID)
+	}
+	pending, err := r.CountPendingCommentsByArticle(articleIDs)
+	for i := range articles {
+		articles[i].PendingComments = pending[articles[i].ID]
+	}
 
 	return articles, total, err
 }
 
-func (r *Repository) CreateArticleWithTags(article *models.Article, tagIDs []string) error {
+// CreateArticleWithTags 创建文章并关联标签；translationOf 不为空时在同一事务中加入该文章的译文分组
+func (r *Repository) CreateArticleWithTags(article *models.Article, tagIDs []string, translationOf string) error {
+	defer r.clearTranslationSourceCache(translationOf)
+
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(article).Error; err != nil {
 			return err
 		}
+		if err := linkTranslationTx(tx, article.ID, translationOf); err != nil {
+			return err
+		}
 
 		if len(tagIDs) > 0 {
 			var tags []models.Tag
@@ -433,15 +694,20 @@ func (r *Repository) CreateArticleWithTags(article *models.Article, tagIDs []str
 	})
 }
 
-func (r *Repository) UpdateArticleWithTags(id string, updates map[string]interface{}, tagIDs []string) error {
+// UpdateArticleWithTags 更新文章，tagIDs 不为 nil 时替换标签；translationOf 不为空时在同一事务中加入该文章的译文分组
+func (r *Repository) UpdateArticleWithTags(id string, updates map[string]interface{}, tagIDs []string, translationOf string) error {
 	// 清除缓存
 	cacheKey := fmt.Sprintf("article:%s", id)
 	r.redis.Del(context.Background(), cacheKey)
+	defer r.clearTranslationSourceCache(translationOf)
 
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Model(&models.Article{}).Where("id = ?", id).Updates(updates).Error; err != nil {
 			return err
 		}
+		if err := linkTranslationTx(tx, id, translationOf); err != nil {
+			return err
+		}
 
 		if tagIDs != nil {
 			var article models.Article
@@ -464,6 +730,13 @@ func (r *Repository) UpdateArticleWithTags(id string, updates map[string]interfa
 	})
 }
 
+// clearTranslationSourceCache 源文章可能被分配了新的译文分组，清除其缓存
+func (r *Repository) clearTranslationSourceCache(sourceID string) {
+	if sourceID != "" {
+		r.redis.Del(context.Background(), fmt.Sprintf("article:%s", sourceID))
+	}
+}
+
 // ==================== User Management ====================
 
 func (r *Repository) UpdateUser(id string, updates map[string]interface{}) error {
@@ -490,7 +763,23 @@ func (r *Repository) GetAllUsers(page, pageSize int, query string) ([]models.Use
 }
 
 func (r *Repository) DeleteUser(id string) error {
-	return r.db.Delete(&models.User{}, "id = ?", id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// 保留用户发表过的评论，仅解除与账号的关联
+		if err := tx.Model(&models.Comment{}).Where("user_id = ?", id).Update("user_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.Notification{}).Error; err != nil {
+			return err
+		}
+		// 删除针对该用户的举报，保留其提交过的举报
+		if err := tx.Where("target_type = ? AND target_id = ?", ReportTargetUser, id).Delete(&models.Report{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Report{}).Where("reporter_id = ?", id).Update("reporter_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.User{}, "id = ?", id).Error
+	})
 }
 
 // ==================== Donation QRCode ====================
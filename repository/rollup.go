@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+	"github.com/ranp9275-sketch/blog-backend-golang/redislock"
+	"github.com/ranp9275-sketch/blog-backend-golang/useragent"
+	"gorm.io/gorm"
+)
+
+// 浏览拆分维度
+const (
+	BreakdownReferrer    = "referrer"
+	BreakdownUAFamily    = "ua_family" // 浏览器
+	BreakdownOS          = "os"
+	BreakdownDevice      = "device"
+	BreakdownUTMSource   = "utm_source"
+	BreakdownUTMMedium   = "utm_medium"
+	BreakdownUTMCampaign = "utm_campaign"
+)
+
+// breakdownColumns 各拆分维度在 article_views 中对应的列
+var breakdownColumns = map[string]string{
+	BreakdownReferrer:    "referrer",
+	BreakdownUAFamily:    "browser",
+	BreakdownOS:          "os",
+	BreakdownDevice:      "device",
+	BreakdownUTMSource:   "utm_source",
+	BreakdownUTMMedium:   "utm_medium",
+	BreakdownUTMCampaign: "utm_campaign",
+}
+
+const (
+	rollupProgressKey = "views:rollup:last" // 已汇总到的最后一天
+	rollupLockKey     = "views:rollup:lock"
+	rollupRunHour     = 0 // 每天 00:10 汇总前一天
+	rollupRunMinute   = 10
+	retentionBatch    = 5000
+)
+
+// dayRange 某一天 [00:00, 次日 00:00) 的本地时间范围
+func dayRange(day string) (time.Time, time.Time) {
+	start, _ := time.ParseInLocation(statsDayLayout, day, time.Local)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// nextDay 后一天的日期字符串
+func nextDay(day string) string {
+	start, _ := time.ParseInLocation(statsDayLayout, day, time.Local)
+	return start.AddDate(0, 0, 1).Format(statsDayLayout)
+}
+
+// RolledUpThrough 已汇总到的最后一天（YYYY-MM-DD），尚未汇总过时返回空字符串
+func (r *Repository) RolledUpThrough() string {
+	if day, err := r.redis.Get(context.Background(), rollupProgressKey).Result(); err == nil {
+		return day
+	}
+	// Redis 数据丢失时以汇总表为准
+	var day string
+	r.db.Model(&models.ArticleViewRollup{}).Select("COALESCE(MAX(date), '')").Row().Scan(&day)
+	return day
+}
+
+// RunViewRollup 启动时补齐未汇总的日期，之后每天凌晨汇总前一天的浏览数据并清理过期的原始记录；
+// retentionDays 为原始记录保留天数，0 表示永久保留
+func (r *Repository) RunViewRollup(ctx context.Context, retentionDays int) {
+	for {
+		if err := r.RollupViews(ctx, retentionDays); err != nil {
+			log.Printf("[View] Failed to roll up views: %v", err)
+		}
+
+		now := time.Now()
+		next := time.Date(now.Year(), now.Month(), now.Day(), rollupRunHour, rollupRunMinute, 0, 0, time.Local)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+	}
+}
+
+// RollupViews 汇总截至昨天尚未汇总的每一天，然后按保留期清理已汇总的原始记录
+func (r *Repository) RollupViews(ctx context.Context, retentionDays int) error {
+	// 多实例部署时只允许一个实例汇总
+	lock, err := redislock.Acquire(ctx, r.redis, rollupLockKey, time.Hour)
+	if err != nil || lock == nil {
+		return err
+	}
+	defer lock.Release()
+
+	yesterday := time.Now().AddDate(0, 0, -1).Format(statsDayLayout)
+	day := r.RolledUpThrough()
+	if day == "" {
+		// 从最早的浏览记录开始
+		var first sql.NullTime
+		if err := r.db.Model(&models.ArticleView{}).Select("MIN(created_at)").Row().Scan(&first); err != nil {
+			return err
+		}
+		if !first.Valid {
+			return r.redis.Set(ctx, rollupProgressKey, yesterday, 0).Err()
+		}
+		day = first.Time.Format(statsDayLayout)
+	} else {
+		day = nextDay(day)
+	}
+
+	for ; day <= yesterday; day = nextDay(day) {
+		if err := r.rollupDay(day); err != nil {
+			return err
+		}
+		if err := r.redis.Set(ctx, rollupProgressKey, day, 0).Err(); err != nil {
+			return err
+		}
+	}
+
+	if retentionDays > 0 {
+		return r.purgeRawViews(retentionDays)
+	}
+	return nil
+}
+
+// dayAggregate 一篇文章一天内的浏览汇总
+type dayAggregate struct {
+	views      int64
+	visitors   map[[32]byte]struct{}
+	dimensions map[string]map[string]int64 // 维度 -> 取值 -> 浏览量
+}
+
+// viewDimensions 一条浏览记录在各拆分维度上的取值；早期记录没有保存解析结果，按 User-Agent 重新识别
+func viewDimensions(view *models.ArticleView) map[string]string {
+	agent := useragent.Agent{Browser: view.Browser, OS: view.OS, Device: view.Device}
+	if agent.Browser == "" {
+		agent = useragent.Parse(view.UserAgent)
+	}
+	return map[string]string{
+		BreakdownReferrer:    view.Referrer,
+		BreakdownUAFamily:    agent.Browser,
+		BreakdownOS:          agent.OS,
+		BreakdownDevice:      agent.Device,
+		BreakdownUTMSource:   view.UTMSource,
+		BreakdownUTMMedium:   view.UTMMedium,
+		BreakdownUTMCampaign: view.UTMCampaign,
+	}
+}
+
+// topValue 计数最多的取值，直接访问等空值不参与
+func topValue(counts map[string]int64) string {
+	var top string
+	var max int64
+	for value, n := range counts {
+		if value != "" && (n > max || (n == max && value < top)) {
+			top, max = value, n
+		}
+	}
+	return top
+}
+
+// rollupDay 重新计算某一天的汇总，可重复执行
+func (r *Repository) rollupDay(day string) error {
+	start, end := dayRange(day)
+	aggregates := map[string]*dayAggregate{}
+
+	var views []models.ArticleView
+	err := r.db.Select("id", "article_id", "ip", "user_agent", "browser", "os", "device",
+		"referrer", "utm_source", "utm_medium", "utm_campaign").
+		Where("created_at >= ? AND created_at < ?", start, end).
+		FindInBatches(&views, 2000, func(tx *gorm.DB, batch int) error {
+			for i := range views {
+				view := &views[i]
+				agg, ok := aggregates[view.ArticleID]
+				if !ok {
+					agg = &dayAggregate{
+						visitors:   map[[32]byte]struct{}{},
+						dimensions: map[string]map[string]int64{},
+					}
+					aggregates[view.ArticleID] = agg
+				}
+				agg.views++
+				agg.visitors[sha256.Sum256([]byte(view.IP+"|"+view.UserAgent))] = struct{}{}
+				for dimension, value := range viewDimensions(view) {
+					// 空来源表示直接访问，需要保留；其他维度为空表示没有该信息，不单独记录
+					if value == "" && dimension != BreakdownReferrer {
+						continue
+					}
+					if agg.dimensions[dimension] == nil {
+						agg.dimensions[dimension] = map[string]int64{}
+					}
+					agg.dimensions[dimension][value]++
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	rollups := make([]models.ArticleViewRollup, 0, len(aggregates))
+	var breakdowns []models.ArticleViewBreakdown
+	for articleID, agg := range aggregates {
+		rollups = append(rollups, models.ArticleViewRollup{
+			ID:          uuid.New().String(),
+			ArticleID:   articleID,
+			Date:        day,
+			Views:       agg.views,
+			Uniques:     int64(len(agg.visitors)),
+			TopReferrer: topValue(agg.dimensions[BreakdownReferrer]),
+			TopUAFamily: topValue(agg.dimensions[BreakdownUAFamily]),
+		})
+		for dimension, counts := range agg.dimensions {
+			for value, n := range counts {
+				breakdowns = append(breakdowns, models.ArticleViewBreakdown{
+					ID:        uuid.New().String(),
+					ArticleID: articleID,
+					Date:      day,
+					Dimension: dimension,
+					Value:     value,
+					Views:     n,
+				})
+			}
+		}
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("date = ?", day).Delete(&models.ArticleViewRollup{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("date = ?", day).Delete(&models.ArticleViewBreakdown{}).Error; err != nil {
+			return err
+		}
+		if len(rollups) > 0 {
+			if err := tx.CreateInBatches(&rollups, 500).Error; err != nil {
+				return err
+			}
+		}
+		if len(breakdowns) > 0 {
+			if err := tx.CreateInBatches(&breakdowns, 500).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// purgeRawViews 分批删除超过保留期且已汇总的原始浏览记录，避免长时间锁表
+func (r *Repository) purgeRawViews(retentionDays int) error {
+	now := time.Now()
+	cutoff := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, -retentionDays)
+
+	// 未汇总的日期不删除
+	rolled := r.RolledUpThrough()
+	if rolled == "" {
+		return nil
+	}
+	if _, end := dayRange(rolled); end.Before(cutoff) {
+		cutoff = end
+	}
+
+	for {
+		result := r.db.Exec("DELETE FROM article_views WHERE created_at < ? LIMIT ?", cutoff, retentionBatch)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected < retentionBatch {
+			return nil
+		}
+	}
+}
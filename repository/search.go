@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+	"github.com/ranp9275-sketch/blog-backend-golang/search"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	searchIndexName      = "idx_articles_fulltext"       // title, excerpt, content 联合全文索引
+	searchTitleIndexName = "idx_articles_title_fulltext" // 标题单独的全文索引，用于标题加权
+	searchTitleBoost     = 3                             // 标题命中的相关度权重
+	searchMinTermLength  = 2                             // ngram_token_size 默认为 2，更短的词无法通过全文索引匹配
+
+	mysqlErrNoFulltextIndex = 1191 // Can't find FULLTEXT index matching the column list
+
+	searchUpdatesChannel = "search:updates" // 全文索引更新消息
+)
+
+// SearchUpdate 全文索引更新消息，每个实例的索引都在本地磁盘上，通过 Redis 发布订阅让所有实例同步更新
+type SearchUpdate struct {
+	IDs     []string `json:"ids,omitempty"`     // 需要重新同步的文章
+	Rebuild bool     `json:"rebuild,omitempty"` // 从数据库重建整个索引
+}
+
+// PublishSearchUpdate 通知所有实例（包括当前实例）更新全文索引
+func (r *Repository) PublishSearchUpdate(update SearchUpdate) error {
+	data, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	return r.redis.Publish(context.Background(), searchUpdatesChannel, data).Err()
+}
+
+// SubscribeSearchUpdates 订阅全文索引更新消息，调用方负责关闭
+func (r *Repository) SubscribeSearchUpdates(ctx context.Context) *redis.PubSub {
+	return r.redis.Subscribe(ctx, searchUpdatesChannel)
+}
+
+// booleanQuery 转换为 MySQL 布尔模式查询串，每个词都加引号，避免被当作运算符
+func booleanQuery(q search.Query) string {
+	var parts []string
+	for _, group := range q.Groups {
+		quoted := make([]string, len(group))
+		for i, term := range group {
+			quoted[i] = `"` + term + `"`
+		}
+		if len(quoted) == 1 {
+			parts = append(parts, "+"+quoted[0])
+		} else {
+			parts = append(parts, "+("+strings.Join(quoted, " ")+")")
+		}
+	}
+	for _, term := range q.Excluded {
+		parts = append(parts, `-"`+term+`"`)
+	}
+	return strings.Join(parts, " ")
+}
+
+// fulltextIndexable 所有词都能被 ngram 全文索引匹配，过短的词会被全文索引忽略
+func fulltextIndexable(q search.Query) bool {
+	for _, term := range q.Terms() {
+		if utf8.RuneCountInString(term) < searchMinTermLength {
+			return false
+		}
+	}
+	return len(q.Groups) > 0
+}
+
+// EnsureSearchIndex 检查并创建使用 ngram 解析器的全文索引（支持中文分词），AutoMigrate 无法声明解析器；
+// 创建失败时搜索退化为 LIKE 匹配
+func (r *Repository) EnsureSearchIndex() error {
+	r.fulltext.Store(false)
+	indexes := []struct {
+		name    string
+		columns string
+	}{
+		{searchIndexName, "title, excerpt, content"},
+		{searchTitleIndexName, "title"},
+	}
+	for _, index := range indexes {
+		if r.db.Migrator().HasIndex(&models.Article{}, index.name) {
+			continue
+		}
+		log.Printf("[Search] Creating full-text index %s, this may take a while on large tables", index.name)
+		if err := r.db.Exec("ALTER TABLE articles ADD FULLTEXT INDEX " + index.name + " (" + index.columns + ") WITH PARSER ngram").Error; err != nil {
+			return err
+		}
+	}
+	r.fulltext.Store(true)
+	return nil
+}
+
+// searchFilters 按分类、标签、作者和发布年份筛选
+func searchFilters(filters search.Filters) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filters.Category != "" {
+			db = db.Where("category_id = ?", filters.Category)
+		}
+		if filters.Tag != "" {
+			db = db.Where("EXISTS (SELECT 1 FROM article_tags WHERE article_tags.article_id = articles.id AND article_tags.tag_id = ?)", filters.Tag)
+		}
+		if filters.Author != "" {
+			db = db.Where("author_id = ?", filters.Author)
+		}
+		if year, err := strconv.Atoi(filters.Year); err == nil {
+			start := time.Date(year, 1, 1, 0, 0, 0, 0, time.Local)
+			db = db.Where("published_at >= ? AND published_at < ?", start, start.AddDate(1, 0, 0))
+		}
+		return db
+	}
+}
+
+// SearchArticles 搜索已发布文章：有全文索引时按相关度排序（标题命中加权），否则按 LIKE 匹配、按发布时间排序
+func (r *Repository) SearchArticles(query string, filters search.Filters, page, pageSize int) ([]models.Article, int64, error) {
+	q := search.ParseQuery(query)
+	if len(q.Groups) == 0 {
+		return []models.Article{}, 0, nil
+	}
+
+	if r.fulltext.Load() && fulltextIndexable(q) {
+		articles, total, err := r.searchFulltext(q, filters, page, pageSize)
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrNoFulltextIndex {
+			// 索引被删除，之后改用 LIKE 匹配
+			log.Printf("[Search] Full-text index missing, falling back to LIKE search")
+			r.fulltext.Store(false)
+		} else {
+			return articles, total, err
+		}
+	}
+	return r.searchLike(q, filters, page, pageSize)
+}
+
+func (r *Repository) searchFulltext(q search.Query, filters search.Filters, page, pageSize int) ([]models.Article, int64, error) {
+	var articles []models.Article
+	var total int64
+
+	against := booleanQuery(q)
+	where := func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ? AND MATCH(title, excerpt, content) AGAINST(? IN BOOLEAN MODE)", "published", against)
+	}
+
+	if err := r.db.Model(&models.Article{}).Scopes(where, searchFilters(filters)).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := r.db.Preload("Category").Preload("Author").Preload("Tags").
+		Select("articles.*, MATCH(title) AGAINST(? IN BOOLEAN MODE) * ? + MATCH(title, excerpt, content) AGAINST(? IN BOOLEAN MODE) AS relevance",
+			against, searchTitleBoost, against).
+		Scopes(where, searchFilters(filters)).
+		Offset(offset).Limit(pageSize).
+		Order("relevance DESC, published_at DESC").
+		Find(&articles).Error
+
+	return articles, total, err
+}
+
+func (r *Repository) searchLike(q search.Query, filters search.Filters, page, pageSize int) ([]models.Article, int64, error) {
+	var articles []models.Article
+	var total int64
+
+	const match = "(title LIKE ? OR content LIKE ? OR excerpt LIKE ?)"
+	where := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("status = ?", "published")
+		for _, group := range q.Groups {
+			conds := make([]string, len(group))
+			var args []interface{}
+			for i, term := range group {
+				pattern := likePattern(term)
+				conds[i] = match
+				args = append(args, pattern, pattern, pattern)
+			}
+			db = db.Where("("+strings.Join(conds, " OR ")+")", args...)
+		}
+		for _, term := range q.Excluded {
+			pattern := likePattern(term)
+			db = db.Where("NOT "+match, pattern, pattern, pattern)
+		}
+		return db
+	}
+
+	if err := r.db.Model(&models.Article{}).Scopes(where, searchFilters(filters)).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := r.db.Preload("Category").Preload("Author").Preload("Tags").
+		Scopes(where, searchFilters(filters)).
+		Offset(offset).Limit(pageSize).
+		Order("published_at DESC").
+		Find(&articles).Error
+
+	return articles, total, err
+}
+
+// likePattern 转义 LIKE 通配符后包装为包含匹配
+func likePattern(term string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term) + "%"
+}
+
+// GetPublishedArticlesByIDs 按给定顺序返回其中已发布的文章，用于加载搜索索引的命中结果
+func (r *Repository) GetPublishedArticlesByIDs(ids []string) ([]models.Article, error) {
+	articles := []models.Article{}
+	if len(ids) == 0 {
+		return articles, nil
+	}
+
+	var found []models.Article
+	if err := r.db.Preload("Category").Preload("Author").Preload("Tags").
+		Where("id IN ? AND status = ?", ids, "published").
+		Find(&found).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[string]models.Article, len(found))
+	for _, article := range found {
+		byID[article.ID] = article
+	}
+	for _, id := range ids {
+		if article, ok := byID[id]; ok {
+			articles = append(articles, article)
+		}
+	}
+	return articles, nil
+}
+
+// EachPublishedArticle 分批遍历所有已发布文章（含标签），用于重建搜索索引
+func (r *Repository) EachPublishedArticle(batchSize int, fn func(articles []models.Article) error) error {
+	var articles []models.Article
+	return r.db.Preload("Tags").
+		Where("status = ?", "published").
+		FindInBatches(&articles, batchSize, func(tx *gorm.DB, batch int) error {
+			return fn(articles)
+		}).Error
+}
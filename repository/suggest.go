@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+	"github.com/ranp9275-sketch/blog-backend-golang/search"
+)
+
+const (
+	searchQueriesKey     = "search:queries" // 搜索词 -> 搜索次数
+	searchQueryMaxLength = 50               // 更长的搜索词不统计
+	searchQueriesKept    = 5000             // 只保留搜索次数最多的这么多个词
+	searchQueriesSuggest = 1000             // 参与搜索建议的热门搜索词数量
+	searchQueryMinCount  = 3                // 至少被这么多个访客搜索过才作为建议，避免暴露个别访客的搜索
+	searchQueryDedup     = 24 * time.Hour   // 同一访客在该时间内重复搜索同一个词只计一次
+)
+
+// searchSeenKey 访客在去重窗口内搜索过某个词的标记，搜索词取摘要避免特殊字符进入键名
+func searchSeenKey(query, visitor string) string {
+	sum := sha256.Sum256([]byte(query))
+	return "search:seen:" + hex.EncodeToString(sum[:16]) + ":" + visitor
+}
+
+// TrackSearchQuery 记录访客的一次有结果的搜索，用于热门搜索词建议；
+// 同一访客的重复搜索不计数，搜索次数即搜索过该词的不同访客数
+func (r *Repository) TrackSearchQuery(query, visitor string) {
+	query = search.NormalizeQuery(query)
+	if query == "" || utf8.RuneCountInString(query) > searchQueryMaxLength {
+		return
+	}
+	ctx := context.Background()
+	first, err := r.redis.SetNX(ctx, searchSeenKey(query, visitor), 1, searchQueryDedup).Result()
+	if err != nil {
+		log.Printf("[Search] Failed to track query: %v", err)
+		return
+	}
+	if !first {
+		return
+	}
+	if err := r.redis.ZIncrBy(ctx, searchQueriesKey, 1, query).Err(); err != nil {
+		log.Printf("[Search] Failed to track query: %v", err)
+	}
+}
+
+// LoadSuggestions 加载搜索建议的候选：已发布文章的标题（按浏览量）、标签和分类（按已发布文章数）
+// 以及热门搜索词（按搜索次数）；Redis 不可用时不含热门搜索词
+func (r *Repository) LoadSuggestions() ([]search.Suggestion, error) {
+	var suggestions []search.Suggestion
+
+	var articles []models.Article
+	if err := r.db.Select("id", "title", "slug", "views").
+		Where("status = ?", "published").
+		Find(&articles).Error; err != nil {
+		return nil, err
+	}
+	for _, article := range articles {
+		suggestions = append(suggestions, search.Suggestion{
+			Kind:   search.SuggestArticle,
+			ID:     article.ID,
+			Slug:   article.Slug,
+			Text:   article.Title,
+			Weight: float64(article.Views),
+		})
+	}
+
+	var rows []struct {
+		ID       string
+		Name     string
+		Slug     string
+		Articles int64
+	}
+	if err := r.db.Model(&models.Tag{}).
+		Select("tags.id, tags.name, tags.slug, COUNT(articles.id) AS articles").
+		Joins("LEFT JOIN article_tags ON article_tags.tag_id = tags.id").
+		Joins("LEFT JOIN articles ON articles.id = article_tags.article_id AND articles.status = ?", "published").
+		Group("tags.id, tags.name, tags.slug").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		suggestions = append(suggestions, search.Suggestion{
+			Kind: search.SuggestTag, ID: row.ID, Slug: row.Slug, Text: row.Name, Weight: float64(row.Articles),
+		})
+	}
+
+	rows = nil
+	if err := r.db.Model(&models.Category{}).
+		Select("categories.id, categories.name, categories.slug, COUNT(articles.id) AS articles").
+		Joins("LEFT JOIN articles ON articles.category_id = categories.id AND articles.status = ?", "published").
+		Group("categories.id, categories.name, categories.slug").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		suggestions = append(suggestions, search.Suggestion{
+			Kind: search.SuggestCategory, ID: row.ID, Slug: row.Slug, Text: row.Name, Weight: float64(row.Articles),
+		})
+	}
+
+	ctx := context.Background()
+	// 丢弃长尾搜索词，防止集合无限增长
+	r.redis.ZRemRangeByRank(ctx, searchQueriesKey, 0, -searchQueriesKept-1)
+	queries, err := r.redis.ZRevRangeWithScores(ctx, searchQueriesKey, 0, searchQueriesSuggest-1).Result()
+	if err != nil {
+		log.Printf("[Search] Failed to load popular queries: %v", err)
+		return suggestions, nil
+	}
+	for _, query := range queries {
+		text, _ := query.Member.(string)
+		if query.Score < searchQueryMinCount {
+			break
+		}
+		suggestions = append(suggestions, search.Suggestion{Kind: search.SuggestQuery, Text: text, Weight: query.Score})
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+	"gorm.io/gorm"
+)
+
+// ==================== Article Template ====================
+
+func (r *Repository) GetArticleTemplates() ([]models.ArticleTemplate, error) {
+	var templates []models.ArticleTemplate
+	err := r.db.Preload("DefaultCategory").Preload("DefaultTags").
+		Order("created_at DESC").
+		Find(&templates).Error
+	return templates, err
+}
+
+func (r *Repository) GetArticleTemplateByID(id string) (*models.ArticleTemplate, error) {
+	var template models.ArticleTemplate
+	err := r.db.Preload("DefaultCategory").Preload("DefaultTags").
+		Where("id = ?", id).
+		First(&template).Error
+	return &template, err
+}
+
+func (r *Repository) CreateArticleTemplate(template *models.ArticleTemplate, tagIDs []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(template).Error; err != nil {
+			return err
+		}
+
+		if len(tagIDs) > 0 {
+			var tags []models.Tag
+			if err := tx.Where("id IN ?", tagIDs).Find(&tags).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(template).Association("DefaultTags").Replace(tags); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *Repository) UpdateArticleTemplate(id string, updates map[string]interface{}, tagIDs []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ArticleTemplate{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		if tagIDs != nil {
+			var template models.ArticleTemplate
+			if err := tx.Where("id = ?", id).First(&template).Error; err != nil {
+				return err
+			}
+
+			var tags []models.Tag
+			if len(tagIDs) > 0 {
+				if err := tx.Where("id IN ?", tagIDs).Find(&tags).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Model(&template).Association("DefaultTags").Replace(tags); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *Repository) DeleteArticleTemplate(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM article_template_tags WHERE article_template_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.ArticleTemplate{}, "id = ?", id).Error
+	})
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+	"gorm.io/gorm"
+)
+
+// ==================== Translation ====================
+
+// linkTranslationTx 在保存文章的事务中将文章加入源文章所在的译文分组，源文章尚无分组时以其 ID 作为分组 ID；
+// sourceID 为空时不做任何事
+func linkTranslationTx(tx *gorm.DB, articleID, sourceID string) error {
+	if sourceID == "" {
+		return nil
+	}
+	if articleID == sourceID {
+		return fmt.Errorf("article cannot be a translation of itself")
+	}
+
+	var source models.Article
+	if err := tx.Select("id", "translation_group_id").Where("id = ?", sourceID).First(&source).Error; err != nil {
+		return err
+	}
+
+	groupID := source.ID
+	if source.TranslationGroupID != nil && *source.TranslationGroupID != "" {
+		groupID = *source.TranslationGroupID
+	} else if err := tx.Model(&models.Article{}).Where("id = ?", sourceID).
+		Update("translation_group_id", groupID).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Article{}).Where("id = ?", articleID).
+		Update("translation_group_id", groupID).Error
+}
+
+// GetArticleTranslations 获取与指定文章同组的已发布译文（包含自身）
+func (r *Repository) GetArticleTranslations(id string) ([]models.Article, error) {
+	var article models.Article
+	if err := r.db.Select("id", "translation_group_id").Where("id = ?", id).First(&article).Error; err != nil {
+		return nil, err
+	}
+
+	if article.TranslationGroupID == nil || *article.TranslationGroupID == "" {
+		return []models.Article{}, nil
+	}
+
+	var translations []models.Article
+	err := r.db.Select("id", "title", "slug", "locale", "translation_group_id", "published_at").
+		Where("translation_group_id = ? AND status = ?", *article.TranslationGroupID, "published").
+		Order("locale ASC").
+		Find(&translations).Error
+
+	return translations, err
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+	"github.com/ranp9275-sketch/blog-backend-golang/redislock"
+	"github.com/redis/go-redis/v9"
+)
+
+// 热度事件权重
+const (
+	TrendingWeightView     = 1.0
+	TrendingWeightComment  = 4.0
+	TrendingWeightFavorite = 6.0
+)
+
+const (
+	trendingKey      = "trending:articles"     // 文章 ID -> 以 trendingEpochKey 为基准时间的热度
+	trendingTempKey  = "trending:articles:tmp" // 重新计算时的临时集合
+	trendingEpochKey = "trending:epoch"        // 热度基准时间（Unix 秒）
+	trendingLockKey  = "trending:lock"
+
+	trendingHalfLife = 24 * time.Hour     // 事件热度每 24 小时减半
+	trendingWindow   = 7 * 24 * time.Hour // 只统计最近 7 天的事件，更早的事件热度已不足 1%
+)
+
+// TrendingArticle 热门文章及其当前热度
+type TrendingArticle struct {
+	models.Article
+	Score float64 `json:"trending_score"`
+}
+
+// trendingDecay 从 from 到 to 的衰减系数
+func trendingDecay(from, to time.Time) float64 {
+	return math.Pow(0.5, to.Sub(from).Hours()/trendingHalfLife.Hours())
+}
+
+// TrackTrending 记录一次热度事件：热度按基准时间折算，各文章的相对排名不随时间变化，无需逐条衰减；
+// 尚未计算过热度时忽略，等待下次重新计算
+func (r *Repository) TrackTrending(articleID string, weight float64) {
+	ctx := context.Background()
+	value, err := r.redis.Get(ctx, trendingEpochKey).Result()
+	if err != nil {
+		return
+	}
+	epoch, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return
+	}
+	// 事件发生在基准时间之后，折算到基准时间时热度放大
+	score := weight / trendingDecay(time.Unix(epoch, 0), time.Now())
+	if err := r.redis.ZIncrBy(ctx, trendingKey, score, articleID).Err(); err != nil {
+		log.Printf("[Trending] Failed to track article %s: %v", articleID, err)
+	}
+}
+
+// RunTrending 启动时及之后每隔 interval 从数据库重新计算热度，阻塞直到 ctx 取消
+func (r *Repository) RunTrending(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := r.RecomputeTrending(ctx); err != nil {
+			log.Printf("[Trending] Failed to recompute trending articles: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RecomputeTrending 以当前时间为基准重新计算已发布文章的热度，替换 Redis 中的排行；
+// 同时修正事件累加的误差，并移除已下线文章和超出统计窗口的文章
+func (r *Repository) RecomputeTrending(ctx context.Context) error {
+	// 多实例部署时只允许一个实例计算
+	lock, err := redislock.Acquire(ctx, r.redis, trendingLockKey, 10*time.Minute)
+	if err != nil || lock == nil {
+		return err
+	}
+	defer lock.Release()
+
+	now := time.Now()
+	scores, err := r.computeTrendingScores(now)
+	if err != nil {
+		return err
+	}
+
+	members := make([]redis.Z, 0, len(scores))
+	for articleID, score := range scores {
+		members = append(members, redis.Z{Score: score, Member: articleID})
+	}
+
+	pipe := r.redis.TxPipeline()
+	pipe.Del(ctx, trendingTempKey)
+	if len(members) > 0 {
+		pipe.ZAdd(ctx, trendingTempKey, members...)
+		pipe.Rename(ctx, trendingTempKey, trendingKey)
+	} else {
+		pipe.Del(ctx, trendingKey)
+	}
+	pipe.Set(ctx, trendingEpochKey, now.Unix(), 0)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// computeTrendingScores 按统计窗口内的浏览、评论和收藏计算已发布文章在 now 时刻的热度
+func (r *Repository) computeTrendingScores(now time.Time) (map[string]float64, error) {
+	since := now.Add(-trendingWindow)
+	halfLife := trendingHalfLife.Seconds()
+	scores := map[string]float64{}
+
+	sources := []struct {
+		model  interface{}
+		where  string
+		weight float64
+	}{
+		{&models.ArticleView{}, "created_at >= ?", TrendingWeightView},
+		{&models.Comment{}, "created_at >= ? AND status = 'approved'", TrendingWeightComment},
+		{&models.Favorite{}, "created_at >= ?", TrendingWeightFavorite},
+	}
+	for _, source := range sources {
+		var rows []struct {
+			ArticleID string
+			Score     float64
+		}
+		if err := r.db.Model(source.model).
+			Select("article_id, SUM(POW(0.5, TIMESTAMPDIFF(SECOND, created_at, ?) / ?)) AS score", now, halfLife).
+			Where(source.where, since).
+			Group("article_id").
+			Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			scores[row.ArticleID] += row.Score * source.weight
+		}
+	}
+
+	if len(scores) == 0 {
+		return scores, nil
+	}
+	ids := make([]string, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	var published []string
+	if err := r.db.Model(&models.Article{}).Where("id IN ? AND status = ?", ids, "published").Pluck("id", &published).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[string]float64, len(published))
+	for _, id := range published {
+		result[id] = scores[id]
+	}
+	return result, nil
+}
+
+// GetTrendingArticles 按热度从高到低返回已发布文章；Redis 不可用或尚未计算时直接从数据库计算
+func (r *Repository) GetTrendingArticles(limit int) ([]TrendingArticle, error) {
+	ctx := context.Background()
+	now := time.Now()
+
+	var ids []string
+	scores := map[string]float64{}
+
+	epochValue, err := r.redis.Get(ctx, trendingEpochKey).Result()
+	epoch, parseErr := strconv.ParseInt(epochValue, 10, 64)
+	if err == nil && parseErr == nil {
+		// 多取一些，排除计算之后被下线的文章
+		members, err := r.redis.ZRevRangeWithScores(ctx, trendingKey, 0, int64(limit*2-1)).Result()
+		if err != nil {
+			return nil, err
+		}
+		decay := trendingDecay(time.Unix(epoch, 0), now)
+		for _, member := range members {
+			id, _ := member.Member.(string)
+			ids = append(ids, id)
+			scores[id] = member.Score * decay
+		}
+	} else {
+		if scores, err = r.computeTrendingScores(now); err != nil {
+			return nil, err
+		}
+		ids = topTrending(scores, limit)
+	}
+
+	trending := []TrendingArticle{}
+	if len(ids) == 0 {
+		return trending, nil
+	}
+	var articles []models.Article
+	if err := r.db.Preload("Category").Preload("Author").Preload("Tags").
+		Where("id IN ? AND status = ?", ids, "published").
+		Find(&articles).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[string]models.Article, len(articles))
+	for _, article := range articles {
+		byID[article.ID] = article
+	}
+	for _, id := range ids {
+		if article, ok := byID[id]; ok && len(trending) < limit {
+			trending = append(trending, TrendingArticle{Article: article, Score: scores[id]})
+		}
+	}
+	return trending, nil
+}
+
+// topTrending 热度最高的 limit 篇文章 ID
+func topTrending(scores map[string]float64, limit int) []string {
+	ids := make([]string, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return scores[ids[i]] > scores[ids[j]] })
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids
+}
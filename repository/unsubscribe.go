@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+	"gorm.io/gorm/clause"
+)
+
+// IsEmailUnsubscribed 邮箱是否已退订邮件通知
+func (r *Repository) IsEmailUnsubscribed(email string) bool {
+	var count int64
+	r.db.Model(&models.EmailUnsubscribe{}).
+		Where("email = ?", strings.ToLower(strings.TrimSpace(email))).
+		Count(&count)
+	return count > 0
+}
+
+// UnsubscribeEmail 将邮箱加入退订名单，重复退订不报错
+func (r *Repository) UnsubscribeEmail(email string) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.EmailUnsubscribe{
+		ID:        uuid.New().String(),
+		Email:     strings.ToLower(strings.TrimSpace(email)),
+		CreatedAt: time.Now(),
+	}).Error
+}
@@ -0,0 +1,357 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ranp9275-sketch/blog-backend-golang/models"
+	"github.com/ranp9275-sketch/blog-backend-golang/redislock"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	viewPendingKey  = "views:pending"  // 文章 ID -> 尚未写入数据库的浏览量增量
+	viewFlushingKey = "views:flushing" // 正在写入数据库的增量，写入失败时下次继续
+	viewRawKey      = "views:raw"      // 待批量写入的浏览记录
+	viewUniqueDirty = "views:dirty:uv" // 独立访客数有变化、待写入数据库的文章；不能放在 views:uv: 下，否则会与文章 ID 冲突
+	viewLockKey     = "views:flush:lock"
+	viewBatchSize   = 1000
+
+	viewDedupWindow = 30 * time.Minute // 同一访客在窗口内重复浏览同一文章只计一次
+)
+
+// viewSeenKey 访客在去重窗口内浏览过文章的标记
+func viewSeenKey(articleID, visitor string) string {
+	return "views:seen:" + articleID + ":" + visitor
+}
+
+// viewUniqueKey 文章独立访客的 HyperLogLog
+func viewUniqueKey(articleID string) string {
+	return "views:uv:" + articleID
+}
+
+// bufferedView 缓冲在 Redis 中的浏览记录
+type bufferedView struct {
+	ID          string    `json:"id"`
+	ArticleID   string    `json:"a"`
+	IP          string    `json:"ip"`
+	UserAgent   string    `json:"ua"`
+	Browser     string    `json:"b,omitempty"`
+	OS          string    `json:"os,omitempty"`
+	Device      string    `json:"d,omitempty"`
+	Referrer    string    `json:"r,omitempty"`
+	UTMSource   string    `json:"us,omitempty"`
+	UTMMedium   string    `json:"um,omitempty"`
+	UTMCampaign string    `json:"uc,omitempty"`
+	CreatedAt   time.Time `json:"t"`
+}
+
+// ArticlePublished 文章是否存在且已发布；借助文章详情缓存，缓存在文章修改、下架、删除时清除
+func (r *Repository) ArticlePublished(id string) (bool, error) {
+	if n, err := r.redis.Exists(context.Background(), fmt.Sprintf("article:%s", id)).Result(); err == nil && n > 0 {
+		return true, nil
+	}
+	if _, err := r.GetArticleByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// clearArticleViews 删除文章后清除 Redis 中该文章的浏览缓冲、独立访客和热度数据
+func (r *Repository) clearArticleViews(articleID string) {
+	ctx := context.Background()
+	pipe := r.redis.Pipeline()
+	pipe.Del(ctx, viewUniqueKey(articleID))
+	pipe.HDel(ctx, viewPendingKey, articleID)
+	pipe.HDel(ctx, viewFlushingKey, articleID)
+	pipe.SRem(ctx, viewUniqueDirty, articleID)
+	pipe.ZRem(ctx, trendingKey, articleID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("[View] Failed to clear view data of article %s: %v", articleID, err)
+	}
+}
+
+// RecordView 记录一次浏览，同一访客在去重窗口内重复浏览时不计数，返回是否计数；
+// 计数和浏览记录先缓冲在 Redis，由 FlushViews 批量写入数据库，Redis 不可用时不去重、直接写数据库
+func (r *Repository) RecordView(view *models.ArticleView, visitor string) (bool, error) {
+	ctx := context.Background()
+	first, redisErr := r.redis.SetNX(ctx, viewSeenKey(view.ArticleID, visitor), 1, viewDedupWindow).Result()
+	if redisErr == nil && !first {
+		return false, nil
+	}
+
+	if view.ID == "" {
+		view.ID = uuid.New().String()
+	}
+	data, err := json.Marshal(bufferedView{
+		ID:          view.ID,
+		ArticleID:   view.ArticleID,
+		IP:          view.IP,
+		UserAgent:   view.UserAgent,
+		Browser:     view.Browser,
+		OS:          view.OS,
+		Device:      view.Device,
+		Referrer:    view.Referrer,
+		UTMSource:   view.UTMSource,
+		UTMMedium:   view.UTMMedium,
+		UTMCampaign: view.UTMCampaign,
+		CreatedAt:   view.CreatedAt,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if redisErr == nil {
+		pipe := r.redis.TxPipeline()
+		incr := pipe.HIncrBy(ctx, viewPendingKey, view.ArticleID, 1)
+		pipe.RPush(ctx, viewRawKey, data)
+		pipe.PFAdd(ctx, viewUniqueKey(view.ArticleID), visitor)
+		pipe.SAdd(ctx, viewUniqueDirty, view.ArticleID)
+		_, err := pipe.Exec(ctx)
+		if err == nil || incr.Err() == nil {
+			// MULTI 中个别命令失败时其余命令照常执行，计数已经缓冲，不能再写数据库
+			if err != nil {
+				log.Printf("[View] Partially buffered view of article %s: %v", view.ArticleID, err)
+			}
+			r.TrackTrending(view.ArticleID, TrendingWeightView)
+			return true, nil
+		}
+		// 无法确定事务是否已执行（如连接中断），宁可少记一次也不重复计数
+		return false, err
+	}
+
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(view).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Article{}).Where("id = ?", view.ArticleID).
+			UpdateColumn("views", gorm.Expr("views + ?", 1)).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	r.TrackTrending(view.ArticleID, TrendingWeightView)
+	return true, nil
+}
+
+// UniqueViews 文章独立访客数，取数据库中已保存的值与 Redis 实时估算值中较大者
+func (r *Repository) UniqueViews(articleID string, stored int64) int64 {
+	count, err := r.redis.PFCount(context.Background(), viewUniqueKey(articleID)).Result()
+	if err != nil || count < stored {
+		return stored
+	}
+	return count
+}
+
+// PendingViews 尚未写入数据库的浏览量增量
+func (r *Repository) PendingViews(articleIDs []string) map[string]int64 {
+	pending := make(map[string]int64, len(articleIDs))
+	if len(articleIDs) == 0 {
+		return pending
+	}
+
+	ctx := context.Background()
+	pipe := r.redis.Pipeline()
+	pendingCmd := pipe.HMGet(ctx, viewPendingKey, articleIDs...)
+	flushingCmd := pipe.HMGet(ctx, viewFlushingKey, articleIDs...)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return pending
+	}
+
+	for _, cmd := range []*redis.SliceCmd{pendingCmd, flushingCmd} {
+		for i, value := range cmd.Val() {
+			if s, ok := value.(string); ok {
+				n, _ := strconv.ParseInt(s, 10, 64)
+				pending[articleIDs[i]] += n
+			}
+		}
+	}
+	return pending
+}
+
+// RunViewFlush 定期将缓冲的浏览量和浏览记录写入数据库，阻塞直到 ctx 取消
+func (r *Repository) RunViewFlush(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.FlushViews(ctx); err != nil {
+				log.Printf("[View] Failed to flush views: %v", err)
+			}
+		}
+	}
+}
+
+// FlushViews 将缓冲的浏览量增量和浏览记录批量写入数据库
+func (r *Repository) FlushViews(ctx context.Context) error {
+	// 多实例部署时只允许一个实例写入，避免重复累加
+	lock, err := redislock.Acquire(ctx, r.redis, viewLockKey, time.Minute)
+	if err != nil || lock == nil {
+		return err
+	}
+	defer lock.Release()
+
+	// 各步骤互不依赖，某一步失败时其余步骤照常执行，避免浏览记录在 Redis 中堆积
+	return errors.Join(
+		r.flushViewCounts(ctx),
+		r.flushUniqueViews(ctx),
+		r.flushViewRecords(ctx),
+	)
+}
+
+// flushUniqueViews 将有变化文章的独立访客估算值写入数据库；只增不减，Redis 数据丢失后不会覆盖已保存的值
+func (r *Repository) flushUniqueViews(ctx context.Context) error {
+	articleIDs, err := r.redis.SMembers(ctx, viewUniqueDirty).Result()
+	if err != nil || len(articleIDs) == 0 {
+		return err
+	}
+
+	pipe := r.redis.Pipeline()
+	counts := make([]*redis.IntCmd, len(articleIDs))
+	for i, articleID := range articleIDs {
+		counts[i] = pipe.PFCount(ctx, viewUniqueKey(articleID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		for i, articleID := range articleIDs {
+			if err := tx.Model(&models.Article{}).Where("id = ?", articleID).
+				UpdateColumn("unique_views", gorm.Expr("GREATEST(unique_views, ?)", counts[i].Val())).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	members := make([]interface{}, len(articleIDs))
+	for i, articleID := range articleIDs {
+		members[i] = articleID
+	}
+	return r.redis.SRem(ctx, viewUniqueDirty, members...).Err()
+}
+
+// flushViewCounts 把待写入的增量整体换到 flushing 键后再写库，写库期间的新浏览继续累加到 pending
+func (r *Repository) flushViewCounts(ctx context.Context) error {
+	// 上次写入失败遗留的增量先处理，避免覆盖
+	flushing, err := r.redis.Exists(ctx, viewFlushingKey).Result()
+	if err != nil {
+		return err
+	}
+	if flushing == 0 {
+		pending, err := r.redis.Exists(ctx, viewPendingKey).Result()
+		if err != nil || pending == 0 {
+			return err
+		}
+		if err := r.redis.Rename(ctx, viewPendingKey, viewFlushingKey).Err(); err != nil {
+			return err
+		}
+	}
+
+	deltas, err := r.redis.HGetAll(ctx, viewFlushingKey).Result()
+	if err != nil {
+		return err
+	}
+
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		for articleID, value := range deltas {
+			delta, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || delta <= 0 {
+				continue
+			}
+			if err := tx.Model(&models.Article{}).Where("id = ?", articleID).
+				UpdateColumn("views", gorm.Expr("views + ?", delta)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return r.redis.Del(ctx, viewFlushingKey).Err()
+}
+
+// flushViewRecords 批量写入浏览记录，写入成功后再出队；记录 ID 入队时已生成，重试不会重复插入
+func (r *Repository) flushViewRecords(ctx context.Context) error {
+	for {
+		entries, err := r.redis.LRange(ctx, viewRawKey, 0, viewBatchSize-1).Result()
+		if err != nil || len(entries) == 0 {
+			return err
+		}
+
+		buffered := make([]bufferedView, 0, len(entries))
+		articleIDs := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			var view bufferedView
+			if err := json.Unmarshal([]byte(entry), &view); err == nil {
+				buffered = append(buffered, view)
+				articleIDs = append(articleIDs, view.ArticleID)
+			}
+		}
+
+		// 跳过缓冲期间已删除的文章，避免外键约束失败阻塞队列
+		var existing []string
+		if len(articleIDs) > 0 {
+			if err := r.db.Model(&models.Article{}).Where("id IN ?", articleIDs).Pluck("id", &existing).Error; err != nil {
+				return err
+			}
+		}
+		exists := make(map[string]bool, len(existing))
+		for _, id := range existing {
+			exists[id] = true
+		}
+
+		views := make([]models.ArticleView, 0, len(buffered))
+		for _, view := range buffered {
+			if exists[view.ArticleID] {
+				views = append(views, models.ArticleView{
+					ID:          view.ID,
+					ArticleID:   view.ArticleID,
+					IP:          view.IP,
+					UserAgent:   view.UserAgent,
+					Browser:     view.Browser,
+					OS:          view.OS,
+					Device:      view.Device,
+					Referrer:    view.Referrer,
+					UTMSource:   view.UTMSource,
+					UTMMedium:   view.UTMMedium,
+					UTMCampaign: view.UTMCampaign,
+					CreatedAt:   view.CreatedAt,
+				})
+			}
+		}
+		if len(views) > 0 {
+			if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).
+				Omit("Article").
+				CreateInBatches(&views, 200).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := r.redis.LTrim(ctx, viewRawKey, int64(len(entries)), -1).Err(); err != nil {
+			return err
+		}
+		if len(entries) < viewBatchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,324 @@
+// Package search 文章全文检索：基于 Bleve 的嵌入式索引，使用 CJK 分析器支持中文，
+// 提供高亮片段、按分类 / 标签 / 作者 / 年份的分面统计和筛选。
+package search
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/lang/cjk"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/highlight/format/html"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// 分面字段
+const (
+	FacetCategory = "category"
+	FacetTag      = "tags"
+	FacetAuthor   = "author"
+	FacetYear     = "year"
+)
+
+const (
+	facetSize    = 10
+	batchSize    = 200
+	titleBoost   = 3.0
+	excerptBoost = 1.5
+)
+
+// ErrRebuilding 已有重建任务在执行
+var ErrRebuilding = errors.New("search index is being rebuilt")
+
+// Document 索引中的文章
+type Document struct {
+	Title       string    `json:"title"`
+	Excerpt     string    `json:"excerpt"`
+	Content     string    `json:"content"`  // 纯文本正文
+	Category    string    `json:"category"` // 分类 ID
+	Tags        []string  `json:"tags"`     // 标签 ID
+	Author      string    `json:"author"`   // 作者 ID
+	Year        string    `json:"year"`     // 发布年份
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// Filters 筛选条件，空值表示不限
+type Filters struct {
+	Category string
+	Tag      string
+	Author   string
+	Year     string
+}
+
+// Hit 一条搜索结果
+type Hit struct {
+	ID         string
+	Score      float64
+	Highlights map[string][]string // 字段 -> 高亮片段，命中词用 <mark> 包裹
+}
+
+// Facet 分面中的一个取值及其文章数
+type Facet struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Result 搜索结果
+type Result struct {
+	Total  uint64
+	Hits   []Hit
+	Facets map[string][]Facet
+}
+
+// Index 文章全文索引，可并发使用
+type Index struct {
+	index      bleve.Index
+	rebuilding atomic.Bool
+
+	mu      sync.Mutex
+	touched map[string]bool // 重建期间被实时添加、更新或删除的文章，不在重建时为 nil
+}
+
+// Open 打开 path 处的索引，不存在时新建；created 表示是否为新建的空索引
+func Open(path string) (idx *Index, created bool, err error) {
+	index, err := bleve.Open(path)
+	if errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+		index, err = bleve.New(path, newMapping())
+		created = true
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &Index{index: index}, created, nil
+}
+
+// newMapping 正文类字段使用 CJK 分析器（中日韩文字按二元组切分，其他文字按词切分），
+// 并保存原文和词向量用于高亮；分面字段按原值索引
+func newMapping() mapping.IndexMapping {
+	text := bleve.NewTextFieldMapping()
+	text.Analyzer = cjk.AnalyzerName
+	text.IncludeInAll = false
+
+	keyword := bleve.NewKeywordFieldMapping()
+	keyword.Store = false
+	keyword.IncludeInAll = false
+
+	date := bleve.NewDateTimeFieldMapping()
+	date.IncludeInAll = false
+
+	doc := bleve.NewDocumentStaticMapping()
+	doc.AddFieldMappingsAt("title", text)
+	doc.AddFieldMappingsAt("excerpt", text)
+	doc.AddFieldMappingsAt("content", text)
+	doc.AddFieldMappingsAt(FacetCategory, keyword)
+	doc.AddFieldMappingsAt(FacetTag, keyword)
+	doc.AddFieldMappingsAt(FacetAuthor, keyword)
+	doc.AddFieldMappingsAt(FacetYear, keyword)
+	doc.AddFieldMappingsAt("published_at", date)
+
+	m := bleve.NewIndexMapping()
+	m.DefaultMapping = doc
+	m.DefaultAnalyzer = cjk.AnalyzerName
+	return m
+}
+
+// Close 关闭索引
+func (i *Index) Close() error {
+	return i.index.Close()
+}
+
+// Index 添加或更新文章
+func (i *Index) Index(id string, doc *Document) error {
+	i.touch(id)
+	return i.index.Index(id, doc)
+}
+
+// Delete 删除文章，文章不在索引中时不报错
+func (i *Index) Delete(id string) error {
+	i.touch(id)
+	return i.index.Delete(id)
+}
+
+// touch 记录重建期间的实时写入，重建不会用较旧的数据覆盖它们
+func (i *Index) touch(id string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.touched != nil {
+		i.touched[id] = true
+	}
+}
+
+// Rebuilding 是否有重建任务在执行
+func (i *Index) Rebuilding() bool {
+	return i.rebuilding.Load()
+}
+
+// Rebuild 用 load 逐批提供的文章重建索引，并删除不再提供的文章；load 依次调用 add 添加每篇文章。
+// 重建期间通过 Index、Delete 实时写入的文章以实时写入为准。同一时间只允许一个重建任务
+func (i *Index) Rebuild(load func(add func(id string, doc *Document) error) error) (int, error) {
+	if !i.rebuilding.CompareAndSwap(false, true) {
+		return 0, ErrRebuilding
+	}
+	defer i.rebuilding.Store(false)
+
+	i.mu.Lock()
+	i.touched = map[string]bool{}
+	i.mu.Unlock()
+	defer func() {
+		i.mu.Lock()
+		i.touched = nil
+		i.mu.Unlock()
+	}()
+
+	// 文章先缓冲在 pending 中，提交时持有 mu 并跳过重建期间已被实时写入的文章，
+	// 实时写入要么在提交前被跳过，要么等提交完成后再写入，都不会被较旧的数据覆盖
+	keep := map[string]bool{}
+	pending := make(map[string]*Document, batchSize)
+	flush := func() error { // 调用方持有 mu
+		batch := i.index.NewBatch()
+		for id, doc := range pending {
+			if i.touched[id] {
+				continue
+			}
+			if err := batch.Index(id, doc); err != nil {
+				return err
+			}
+		}
+		clear(pending)
+		return i.index.Batch(batch)
+	}
+	err := load(func(id string, doc *Document) error {
+		keep[id] = true
+		pending[id] = doc
+		if len(pending) < batchSize {
+			return nil
+		}
+		i.mu.Lock()
+		defer i.mu.Unlock()
+		return flush()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// 收尾期间暂停实时写入，避免与下面的删除交错
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if err := flush(); err != nil {
+		return 0, err
+	}
+
+	// 删除已下线或已删除的文章，重建期间实时添加的文章不在 load 的结果中，需要保留
+	count, err := i.index.DocCount()
+	if err != nil {
+		return 0, err
+	}
+	all := bleve.NewSearchRequestOptions(bleve.NewMatchAllQuery(), int(count), 0, false)
+	existing, err := i.index.Search(all)
+	if err != nil {
+		return 0, err
+	}
+	batch := i.index.NewBatch()
+	for _, hit := range existing.Hits {
+		if !keep[hit.ID] && !i.touched[hit.ID] {
+			batch.Delete(hit.ID)
+		}
+	}
+	if err := i.index.Batch(batch); err != nil {
+		return 0, err
+	}
+	return len(keep), nil
+}
+
+// Search 按查询词和筛选条件搜索，按相关度排序（标题命中加权），同时返回高亮片段和分面统计
+func (i *Index) Search(q Query, filters Filters, page, pageSize int) (*Result, error) {
+	must := bleve.NewConjunctionQuery()
+	for _, group := range q.Groups {
+		terms := make([]query.Query, len(group))
+		for j, term := range group {
+			terms[j] = termQuery(term)
+		}
+		must.AddQuery(bleve.NewDisjunctionQuery(terms...))
+	}
+	for field, value := range map[string]string{
+		FacetCategory: filters.Category,
+		FacetTag:      filters.Tag,
+		FacetAuthor:   filters.Author,
+		FacetYear:     filters.Year,
+	} {
+		if value != "" {
+			filter := bleve.NewTermQuery(value)
+			filter.SetField(field)
+			must.AddQuery(filter)
+		}
+	}
+
+	boolean := bleve.NewBooleanQuery()
+	boolean.AddMust(must)
+	for _, term := range q.Excluded {
+		boolean.AddMustNot(termQuery(term))
+	}
+
+	req := bleve.NewSearchRequestOptions(boolean, pageSize, (page-1)*pageSize, false)
+	req.SortBy([]string{"-_score", "-published_at"})
+	req.Highlight = bleve.NewHighlightWithStyle(html.Name)
+	req.Highlight.AddField("title")
+	req.Highlight.AddField("excerpt")
+	req.Highlight.AddField("content")
+	for _, field := range []string{FacetCategory, FacetTag, FacetAuthor, FacetYear} {
+		req.AddFacet(field, bleve.NewFacetRequest(field, facetSize))
+	}
+
+	res, err := i.index.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Total: res.Total, Hits: []Hit{}, Facets: map[string][]Facet{}}
+	for _, hit := range res.Hits {
+		// 没有命中的字段也会返回原文片段，只保留带高亮的片段
+		highlights := map[string][]string{}
+		for field, fragments := range hit.Fragments {
+			for _, fragment := range fragments {
+				if strings.Contains(fragment, "<mark>") {
+					highlights[field] = append(highlights[field], fragment)
+				}
+			}
+		}
+		result.Hits = append(result.Hits, Hit{ID: hit.ID, Score: hit.Score, Highlights: highlights})
+	}
+	for name, facet := range res.Facets {
+		values := []Facet{}
+		if facet.Terms != nil {
+			for _, term := range facet.Terms.Terms() {
+				values = append(values, Facet{Value: term.Term, Count: term.Count})
+			}
+		}
+		result.Facets[name] = values
+	}
+	return result, nil
+}
+
+// termQuery 在标题、摘要和正文中按短语匹配一个词，标题命中权重最高
+func termQuery(term string) query.Query {
+	fields := []struct {
+		name  string
+		boost float64
+	}{
+		{"title", titleBoost},
+		{"excerpt", excerptBoost},
+		{"content", 1},
+	}
+	queries := make([]query.Query, len(fields))
+	for i, field := range fields {
+		phrase := bleve.NewMatchPhraseQuery(term)
+		phrase.SetField(field.name)
+		phrase.SetBoost(field.boost)
+		queries[i] = phrase
+	}
+	return bleve.NewDisjunctionQuery(queries...)
+}
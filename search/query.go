@@ -0,0 +1,71 @@
+package search
+
+import "strings"
+
+// Query 解析后的搜索条件：Groups 之间为 AND，组内的词为 OR；Excluded 为排除词
+type Query struct {
+	Groups   [][]string
+	Excluded []string
+}
+
+// Terms 查询中出现的所有词，包括排除词
+func (q Query) Terms() []string {
+	terms := append([]string{}, q.Excluded...)
+	for _, group := range q.Groups {
+		terms = append(terms, group...)
+	}
+	return terms
+}
+
+// ParseQuery 解析搜索语法：空格分隔的词都必须出现，"带引号的短语" 按整体匹配，
+// -词 表示排除，词 OR 词 表示任一出现即可
+func ParseQuery(input string) Query {
+	var tokens []string
+	var negated []bool
+	for rest := strings.TrimSpace(input); rest != ""; rest = strings.TrimSpace(rest) {
+		neg := false
+		if rest[0] == '-' {
+			neg = true
+			rest = rest[1:]
+		}
+		var token string
+		if strings.HasPrefix(rest, `"`) {
+			if end := strings.Index(rest[1:], `"`); end >= 0 {
+				token, rest = rest[1:end+1], rest[end+2:]
+			} else {
+				token, rest = rest[1:], ""
+			}
+		} else if end := strings.IndexAny(rest, " \t\n　"); end >= 0 {
+			token, rest = rest[:end], rest[end:]
+		} else {
+			token, rest = rest, ""
+		}
+		// 去掉各类检索语法的运算符，剩余部分作为普通文本
+		token = strings.Join(strings.Fields(strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`+-<>()~*"@`, r) {
+				return ' '
+			}
+			return r
+		}, token)), " ")
+		if token != "" {
+			tokens = append(tokens, token)
+			negated = append(negated, neg)
+		}
+	}
+
+	var q Query
+	for i := 0; i < len(tokens); i++ {
+		if negated[i] {
+			q.Excluded = append(q.Excluded, tokens[i])
+			continue
+		}
+		if tokens[i] == "OR" && len(q.Groups) > 0 && i+1 < len(tokens) && !negated[i+1] {
+			last := len(q.Groups) - 1
+			q.Groups[last] = append(q.Groups[last], tokens[i+1])
+			i++
+			continue
+		}
+		q.Groups = append(q.Groups, []string{tokens[i]})
+	}
+	return q
+}
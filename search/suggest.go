@@ -0,0 +1,157 @@
+package search
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// 搜索建议的类别
+const (
+	SuggestArticle  = "articles"   // 标题以输入开头的文章
+	SuggestTag      = "tags"       // 名称以输入开头的标签
+	SuggestCategory = "categories" // 名称以输入开头的分类
+	SuggestQuery    = "queries"    // 以输入开头的热门搜索词
+)
+
+// SuggestMaxLimit 每个类别最多返回的建议数，前缀树的每个节点只保留这么多
+const SuggestMaxLimit = 10
+
+var suggestKinds = []string{SuggestArticle, SuggestTag, SuggestCategory, SuggestQuery}
+
+// Suggestion 一条搜索建议
+type Suggestion struct {
+	Kind   string  `json:"-"`
+	ID     string  `json:"id,omitempty"`
+	Slug   string  `json:"slug,omitempty"`
+	Text   string  `json:"text"`
+	Weight float64 `json:"weight"` // 文章为浏览量，标签和分类为文章数，搜索词为搜索过的访客数
+}
+
+// NormalizeQuery 统一大小写和空白，用于前缀匹配和统计搜索词
+func NormalizeQuery(input string) string {
+	return strings.ToLower(strings.Join(strings.Fields(input), " "))
+}
+
+type trieNode struct {
+	children map[rune]*trieNode
+	top      []Suggestion // 以该节点为前缀的建议中权重最高的若干条
+}
+
+func (n *trieNode) insert(key string, item Suggestion) {
+	node := n
+	for _, r := range key {
+		child, ok := node.children[r]
+		if !ok {
+			child = &trieNode{}
+			if node.children == nil {
+				node.children = map[rune]*trieNode{}
+			}
+			node.children[r] = child
+		}
+		node = child
+	}
+	node.top = append(node.top, item)
+}
+
+// finish 自底向上汇总子树中权重最高的建议，查询时只需找到前缀对应的节点
+func (n *trieNode) finish() {
+	for _, child := range n.children {
+		child.finish()
+		n.top = append(n.top, child.top...)
+	}
+	sort.SliceStable(n.top, func(i, j int) bool {
+		if n.top[i].Weight != n.top[j].Weight {
+			return n.top[i].Weight > n.top[j].Weight
+		}
+		return n.top[i].Text < n.top[j].Text
+	})
+	if len(n.top) > SuggestMaxLimit {
+		n.top = append([]Suggestion(nil), n.top[:SuggestMaxLimit]...)
+	}
+}
+
+func (n *trieNode) lookup(prefix string) []Suggestion {
+	node := n
+	for _, r := range prefix {
+		if node = node.children[r]; node == nil {
+			return nil
+		}
+	}
+	return node.top
+}
+
+// Suggester 搜索建议：定期从数据源加载文章标题、标签、分类和热门搜索词，构建内存前缀树，查询不访问数据库
+type Suggester struct {
+	load  func() ([]Suggestion, error)
+	tries atomic.Pointer[map[string]*trieNode]
+}
+
+// NewSuggester 创建搜索建议，load 返回全部候选建议
+func NewSuggester(load func() ([]Suggestion, error)) *Suggester {
+	return &Suggester{load: load}
+}
+
+// Refresh 重新加载候选建议并替换前缀树
+func (s *Suggester) Refresh() error {
+	items, err := s.load()
+	if err != nil {
+		return err
+	}
+	tries := map[string]*trieNode{}
+	for _, kind := range suggestKinds {
+		tries[kind] = &trieNode{}
+	}
+	for _, item := range items {
+		if root, ok := tries[item.Kind]; ok {
+			root.insert(NormalizeQuery(item.Text), item)
+		}
+	}
+	for _, root := range tries {
+		root.finish()
+	}
+	s.tries.Store(&tries)
+	return nil
+}
+
+// Run 启动时及之后每隔 interval 刷新前缀树，阻塞直到 ctx 取消
+func (s *Suggester) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Refresh(); err != nil {
+			log.Printf("[Search] Failed to refresh suggestions: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Suggest 返回各类别中以 prefix 开头（忽略大小写）、权重最高的至多 limit 条建议；尚未加载时返回空结果
+func (s *Suggester) Suggest(prefix string, limit int) map[string][]Suggestion {
+	if limit > SuggestMaxLimit {
+		limit = SuggestMaxLimit
+	}
+	prefix = NormalizeQuery(prefix)
+
+	result := make(map[string][]Suggestion, len(suggestKinds))
+	tries := s.tries.Load()
+	for _, kind := range suggestKinds {
+		result[kind] = []Suggestion{}
+		if tries == nil {
+			continue
+		}
+		top := (*tries)[kind].lookup(prefix)
+		if len(top) > limit {
+			top = top[:limit]
+		}
+		result[kind] = append(result[kind], top...)
+	}
+	return result
+}
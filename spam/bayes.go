@@ -0,0 +1,161 @@
+package spam
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	bayesDocsKey   = "spam:bayes:docs"    // 各类别样本数
+	bayesTotalsKey = "spam:bayes:totals"  // 各类别词总数
+	bayesTokensKey = "spam:bayes:tokens:" // 各类别词频，后接类别名
+	bayesMinDocs   = 10                   // 每个类别至少需要的样本数
+	bayesWeight    = 1.5                  // 概率映射到分值的权重
+)
+
+// BayesChecker 基于 Redis 存储词频的多项式朴素贝叶斯分类器
+type BayesChecker struct {
+	rdb *redis.Client
+}
+
+// NewBayesChecker 创建贝叶斯分类器
+func NewBayesChecker(rdb *redis.Client) *BayesChecker {
+	return &BayesChecker{rdb: rdb}
+}
+
+func (b *BayesChecker) Name() string { return "bayes" }
+
+// SpamProbability 计算评论为垃圾的概率；样本不足时 ok 为 false
+func (b *BayesChecker) SpamProbability(ctx context.Context, in *Input) (prob float64, ok bool) {
+	docs, err := b.rdb.HMGet(ctx, bayesDocsKey, ClassSpam, ClassHam).Result()
+	if err != nil {
+		return 0, false
+	}
+	spamDocs, hamDocs := toInt(docs[0]), toInt(docs[1])
+	if spamDocs < bayesMinDocs || hamDocs < bayesMinDocs {
+		return 0, false
+	}
+
+	tokens := tokenize(in)
+	if len(tokens) == 0 {
+		return 0, false
+	}
+	unique := make([]string, 0, len(tokens))
+	for token := range tokens {
+		unique = append(unique, token)
+	}
+
+	pipe := b.rdb.Pipeline()
+	totalsCmd := pipe.HMGet(ctx, bayesTotalsKey, ClassSpam, ClassHam)
+	spamCmd := pipe.HMGet(ctx, bayesTokensKey+ClassSpam, unique...)
+	hamCmd := pipe.HMGet(ctx, bayesTokensKey+ClassHam, unique...)
+	spamVocab := pipe.HLen(ctx, bayesTokensKey+ClassSpam)
+	hamVocab := pipe.HLen(ctx, bayesTokensKey+ClassHam)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, false
+	}
+
+	totals := totalsCmd.Val()
+	spamTotal, hamTotal := float64(toInt(totals[0])), float64(toInt(totals[1]))
+	vocab := float64(spamVocab.Val() + hamVocab.Val())
+	spamCounts, hamCounts := spamCmd.Val(), hamCmd.Val()
+
+	// 对数空间计算，Laplace 平滑
+	logSpam := math.Log(float64(spamDocs) / float64(spamDocs+hamDocs))
+	logHam := math.Log(float64(hamDocs) / float64(spamDocs+hamDocs))
+	for i, token := range unique {
+		n := float64(tokens[token])
+		logSpam += n * math.Log((float64(toInt(spamCounts[i]))+1)/(spamTotal+vocab))
+		logHam += n * math.Log((float64(toInt(hamCounts[i]))+1)/(hamTotal+vocab))
+	}
+
+	return 1 / (1 + math.Exp(logHam-logSpam)), true
+}
+
+func (b *BayesChecker) Check(ctx context.Context, in *Input) Signal {
+	prob, ok := b.SpamProbability(ctx, in)
+	if !ok {
+		return Signal{}
+	}
+	// 0.5 附近不表态，两端映射到 [-bayesWeight, bayesWeight]
+	score := (prob - 0.5) * 2 * bayesWeight
+	if math.Abs(score) < 0.3 {
+		return Signal{}
+	}
+	return Signal{Score: score, Reason: fmt.Sprintf("spam probability %.2f", prob)}
+}
+
+// Train 更新词频和样本数，delta 为 -1 时撤销
+func (b *BayesChecker) Train(ctx context.Context, in *Input, class string, delta int64) error {
+	tokens := tokenize(in)
+
+	var total int64
+	pipe := b.rdb.Pipeline()
+	for token, count := range tokens {
+		pipe.HIncrBy(ctx, bayesTokensKey+class, token, int64(count)*delta)
+		total += int64(count)
+	}
+	pipe.HIncrBy(ctx, bayesTotalsKey, class, total*delta)
+	pipe.HIncrBy(ctx, bayesDocsKey, class, delta)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// tokenize 提取特征：英文单词、中文二元组、链接域名和邮箱域名
+func tokenize(in *Input) map[string]int {
+	tokens := make(map[string]int)
+
+	var word []rune
+	var han []rune
+	flush := func() {
+		if len(word) >= 2 && len(word) <= 30 {
+			tokens[strings.ToLower(string(word))]++
+		}
+		word = word[:0]
+		if len(han) == 1 {
+			tokens[string(han)]++
+		}
+		for i := 0; i+1 < len(han); i++ {
+			tokens[string(han[i:i+2])]++
+		}
+		han = han[:0]
+	}
+
+	for _, r := range in.Content {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			if len(word) > 0 {
+				flush()
+			}
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if len(han) > 0 {
+				flush()
+			}
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	for _, m := range linkRe.FindAllString(in.Content, -1) {
+		host := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(m), "https://"), "http://")
+		if i := strings.IndexAny(host, "/?#)"); i >= 0 {
+			host = host[:i]
+		}
+		if host != "" {
+			tokens["link:"+host]++
+		}
+	}
+	if i := strings.LastIndex(in.Email, "@"); i >= 0 {
+		tokens["email:"+in.Email[i+1:]]++
+	}
+
+	return tokens
+}
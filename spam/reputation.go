@@ -0,0 +1,104 @@
+package spam
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	reputationKeyPrefix = "spam:reputation:"
+	rateKeyPrefix       = "spam:rate:ip:"
+	rateWindow          = time.Minute
+	rateLimit           = 5 // 每个 IP 每分钟允许的评论数
+)
+
+// ReputationChecker 基于 Redis 中记录的 IP、邮箱、登录用户历史审核结果和提交频率打分
+type ReputationChecker struct {
+	rdb *redis.Client
+}
+
+// NewReputationChecker 创建信誉检测器
+func NewReputationChecker(rdb *redis.Client) *ReputationChecker {
+	return &ReputationChecker{rdb: rdb}
+}
+
+func (r *ReputationChecker) Name() string { return "reputation" }
+
+func reputationKey(kind, value string) string {
+	return reputationKeyPrefix + kind + ":" + value
+}
+
+func (r *ReputationChecker) Check(ctx context.Context, in *Input) Signal {
+	var score float64
+	var reasons []string
+
+	// 短时间内频繁提交
+	if in.IP != "" {
+		key := rateKeyPrefix + in.IP
+		if count, err := r.rdb.Incr(ctx, key).Result(); err == nil {
+			if count == 1 {
+				r.rdb.Expire(ctx, key, rateWindow)
+			}
+			if count > rateLimit {
+				score += 1
+				reasons = append(reasons, fmt.Sprintf("%d comments from ip in the last minute", count))
+			}
+		}
+	}
+
+	// 邮箱未经验证，任何人都可以冒用，只用于加分，不作为正常评论的依据
+	subjects := []struct {
+		kind, value string
+		credit      bool
+	}{{"ip", in.IP, true}, {"email", in.Email, false}, {"user", in.UserID, true}}
+	for _, subject := range subjects {
+		if subject.value == "" {
+			continue
+		}
+		counts, err := r.rdb.HMGet(ctx, reputationKey(subject.kind, subject.value), ClassSpam, ClassHam).Result()
+		if err != nil {
+			continue
+		}
+		spamCount, hamCount := toInt(counts[0]), toInt(counts[1])
+		if spamCount+hamCount == 0 {
+			continue
+		}
+
+		// 历史垃圾比例越高分越高；有足够多正常记录且无垃圾记录时减分
+		ratio := float64(spamCount) / float64(spamCount+hamCount)
+		switch {
+		case spamCount > 0 && ratio >= 0.5:
+			s := math.Min(0.4*float64(spamCount), 1.2)
+			score += s
+			reasons = append(reasons, fmt.Sprintf("%s has %d spam / %d ham", subject.kind, spamCount, hamCount))
+		case subject.credit && spamCount == 0 && hamCount >= 2:
+			score -= math.Min(0.2*float64(hamCount), 0.6)
+			reasons = append(reasons, fmt.Sprintf("%s has %d approved comments", subject.kind, hamCount))
+		}
+	}
+
+	if score == 0 {
+		return Signal{}
+	}
+	return Signal{Score: score, Reason: joinReasons(reasons)}
+}
+
+// Train 记录 IP、邮箱和登录用户的审核结果
+func (r *ReputationChecker) Train(ctx context.Context, in *Input, class string, delta int64) error {
+	pipe := r.rdb.Pipeline()
+	if in.IP != "" {
+		pipe.HIncrBy(ctx, reputationKey("ip", in.IP), class, delta)
+	}
+	if in.Email != "" {
+		pipe.HIncrBy(ctx, reputationKey("email", in.Email), class, delta)
+	}
+	if in.UserID != "" {
+		pipe.HIncrBy(ctx, reputationKey("user", in.UserID), class, delta)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
@@ -0,0 +1,150 @@
+package spam
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultBlocklist 默认屏蔽词
+var defaultBlocklist = []string{
+	"viagra", "cialis", "casino", "porn", "loan", "crypto giveaway",
+	"博彩", "赌场", "代开发票", "发票", "刷单", "兼职日结", "加微信", "裸聊", "六合彩",
+}
+
+var linkRe = regexp.MustCompile(`(?i)(https?://|www\.)\S+|\[[^\]]*\]\([^)]*\)|<a\s`)
+
+// HoneypotChecker 蜜罐字段被填写时判定为机器人
+type HoneypotChecker struct{}
+
+func (HoneypotChecker) Name() string { return "honeypot" }
+
+func (HoneypotChecker) Check(_ context.Context, in *Input) Signal {
+	if strings.TrimSpace(in.Honeypot) != "" {
+		return Signal{Score: 2, Reason: "honeypot field filled"}
+	}
+	return Signal{}
+}
+
+// LinkChecker 链接数量超过阈值时加分
+type LinkChecker struct {
+	MaxLinks int
+}
+
+func (LinkChecker) Name() string { return "links" }
+
+func (l LinkChecker) Check(_ context.Context, in *Input) Signal {
+	count := len(linkRe.FindAllString(in.Content, -1))
+	if strings.Contains(strings.ToLower(in.Author), "http") {
+		count++
+	}
+	if count <= l.MaxLinks {
+		return Signal{}
+	}
+	score := math.Min(0.4*float64(count-l.MaxLinks), 1.5)
+	return Signal{Score: score, Reason: fmt.Sprintf("%d links", count)}
+}
+
+// BlocklistChecker 命中屏蔽词时加分
+type BlocklistChecker struct {
+	words []string
+}
+
+// NewBlocklistChecker 创建屏蔽词检测器，屏蔽词不区分大小写
+func NewBlocklistChecker(words []string) *BlocklistChecker {
+	b := &BlocklistChecker{}
+	for _, w := range words {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			b.words = append(b.words, w)
+		}
+	}
+	return b
+}
+
+func (b *BlocklistChecker) Name() string { return "blocklist" }
+
+func (b *BlocklistChecker) Check(_ context.Context, in *Input) Signal {
+	text := strings.ToLower(in.Author + " " + in.Email + " " + in.Content)
+	var hits []string
+	for _, w := range b.words {
+		if strings.Contains(text, w) {
+			hits = append(hits, w)
+		}
+	}
+	if len(hits) == 0 {
+		return Signal{}
+	}
+	return Signal{Score: math.Min(float64(len(hits)), 2), Reason: "blocked words: " + strings.Join(hits, ",")}
+}
+
+// TokenChecker 校验页面停留时间令牌：提交过快或令牌缺失、无效时加分
+type TokenChecker struct {
+	secret   []byte
+	minDelay time.Duration
+	maxAge   time.Duration
+	now      func() time.Time
+}
+
+// NewTokenChecker 创建停留时间令牌检测器
+func NewTokenChecker(secret string, minSeconds int) *TokenChecker {
+	if secret == "" {
+		secret = "your-secret-key"
+	}
+	return &TokenChecker{
+		secret:   []byte(secret),
+		minDelay: time.Duration(minSeconds) * time.Second,
+		maxAge:   24 * time.Hour,
+		now:      time.Now,
+	}
+}
+
+func (t *TokenChecker) Name() string { return "form_token" }
+
+// Issue 签发令牌：base64(articleID|unix时间) + "." + HMAC
+func (t *TokenChecker) Issue(articleID string) string {
+	payload := articleID + "|" + strconv.FormatInt(t.now().Unix(), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + t.sign(payload)
+}
+
+func (t *TokenChecker) sign(payload string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (t *TokenChecker) Check(_ context.Context, in *Input) Signal {
+	if in.FormToken == "" {
+		return Signal{Score: 0.5, Reason: "missing form token"}
+	}
+
+	parts := strings.SplitN(in.FormToken, ".", 2)
+	if len(parts) != 2 {
+		return Signal{Score: 1, Reason: "malformed form token"}
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || !hmac.Equal([]byte(t.sign(string(raw))), []byte(parts[1])) {
+		return Signal{Score: 1, Reason: "invalid form token"}
+	}
+
+	fields := strings.SplitN(string(raw), "|", 2)
+	issuedAt, err := strconv.ParseInt(fields[len(fields)-1], 10, 64)
+	if len(fields) != 2 || err != nil || fields[0] != in.ArticleID {
+		return Signal{Score: 1, Reason: "form token does not match article"}
+	}
+
+	elapsed := t.now().Sub(time.Unix(issuedAt, 0))
+	switch {
+	case elapsed < t.minDelay:
+		return Signal{Score: 1, Reason: fmt.Sprintf("submitted %.1fs after page load", elapsed.Seconds())}
+	case elapsed > t.maxAge:
+		return Signal{Score: 0.3, Reason: "form token expired"}
+	}
+	return Signal{}
+}
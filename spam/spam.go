@@ -0,0 +1,190 @@
+// Package spam 实现评论反垃圾检测流水线：规则、蜜罐、停留时间令牌、
+// IP/邮箱信誉和可训练的朴素贝叶斯分类器。
+package spam
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 检测结论
+const (
+	VerdictHam    = "ham"    // 高置信度正常评论，可自动通过
+	VerdictSpam   = "spam"   // 判定为垃圾评论
+	VerdictUnsure = "unsure" // 交由人工审核
+)
+
+// 训练类别
+const (
+	ClassSpam = "spam"
+	ClassHam  = "ham"
+)
+
+// Input 待检测的评论
+type Input struct {
+	ArticleID string
+	Author    string
+	Email     string // 评论者自称的邮箱，未经验证
+	UserID    string // 登录用户 ID，匿名评论为空
+	Content   string
+	IP        string
+	UserAgent string
+	Honeypot  string // 蜜罐字段，正常用户看不到也不会填写
+	FormToken string // 页面加载时下发的停留时间令牌
+}
+
+// Signal 单个检测器给出的分值，正数偏向垃圾，负数偏向正常
+type Signal struct {
+	Checker string  `json:"checker"`
+	Score   float64 `json:"score"`
+	Reason  string  `json:"reason"`
+}
+
+// Result 流水线检测结果
+type Result struct {
+	Score   float64  `json:"score"`
+	Verdict string   `json:"verdict"`
+	Signals []Signal `json:"signals"`
+}
+
+// Reasons 返回所有命中信号的原因
+func (r *Result) Reasons() []string {
+	reasons := make([]string, 0, len(r.Signals))
+	for _, s := range r.Signals {
+		reasons = append(reasons, s.Checker+": "+s.Reason)
+	}
+	return reasons
+}
+
+// Checker 检测器接口，返回的 Signal.Score 为 0 时视为未命中
+type Checker interface {
+	Name() string
+	Check(ctx context.Context, in *Input) Signal
+}
+
+// Trainer 可根据管理员的审核结果学习的检测器
+type Trainer interface {
+	Train(ctx context.Context, in *Input, class string, delta int64) error
+}
+
+// Config 反垃圾配置
+type Config struct {
+	Secret          string   // 停留时间令牌签名密钥
+	Blocklist       []string // 屏蔽词
+	MaxLinks        int      // 允许的最大链接数
+	MinSubmitSecond int      // 页面加载到提交的最短秒数
+	SpamThreshold   float64  // 总分大于等于该值判为垃圾
+	HamThreshold    float64  // 总分小于等于该值自动通过
+}
+
+// DefaultConfig 默认配置
+func DefaultConfig() Config {
+	return Config{
+		Blocklist:       append([]string(nil), defaultBlocklist...),
+		MaxLinks:        2,
+		MinSubmitSecond: 3,
+		SpamThreshold:   1.0,
+		HamThreshold:    -0.6,
+	}
+}
+
+// Filter 反垃圾检测流水线
+type Filter struct {
+	cfg      Config
+	checkers []Checker
+	tokens   *TokenChecker
+}
+
+// NewFilter 使用默认检测器创建流水线
+func NewFilter(rdb *redis.Client, cfg Config) *Filter {
+	tokens := NewTokenChecker(cfg.Secret, cfg.MinSubmitSecond)
+	f := &Filter{cfg: cfg, tokens: tokens}
+	f.Use(
+		HoneypotChecker{},
+		tokens,
+		LinkChecker{MaxLinks: cfg.MaxLinks},
+		NewBlocklistChecker(cfg.Blocklist),
+		NewReputationChecker(rdb),
+		NewBayesChecker(rdb),
+	)
+	return f
+}
+
+// Use 追加检测器
+func (f *Filter) Use(checkers ...Checker) {
+	f.checkers = append(f.checkers, checkers...)
+}
+
+// IssueToken 为文章签发停留时间令牌
+func (f *Filter) IssueToken(articleID string) string {
+	return f.tokens.Issue(articleID)
+}
+
+// MinSubmitSeconds 页面加载到提交的最短秒数
+func (f *Filter) MinSubmitSeconds() int {
+	return f.cfg.MinSubmitSecond
+}
+
+// Check 依次运行所有检测器并汇总结论
+func (f *Filter) Check(ctx context.Context, in *Input) *Result {
+	in.Email = strings.ToLower(strings.TrimSpace(in.Email))
+
+	result := &Result{Verdict: VerdictUnsure, Signals: []Signal{}}
+	for _, checker := range f.checkers {
+		signal := checker.Check(ctx, in)
+		if signal.Score == 0 {
+			continue
+		}
+		signal.Checker = checker.Name()
+		result.Score += signal.Score
+		result.Signals = append(result.Signals, signal)
+	}
+
+	switch {
+	case result.Score >= f.cfg.SpamThreshold:
+		result.Verdict = VerdictSpam
+	case result.Score <= f.cfg.HamThreshold:
+		result.Verdict = VerdictHam
+	}
+
+	return result
+}
+
+// Train 将评论作为 spam/ham 样本交给所有可训练的检测器
+func (f *Filter) Train(ctx context.Context, in *Input, class string) error {
+	return f.train(ctx, in, class, 1)
+}
+
+// Untrain 撤销之前的训练（管理员改判时使用）
+func (f *Filter) Untrain(ctx context.Context, in *Input, class string) error {
+	return f.train(ctx, in, class, -1)
+}
+
+func (f *Filter) train(ctx context.Context, in *Input, class string, delta int64) error {
+	in.Email = strings.ToLower(strings.TrimSpace(in.Email))
+	for _, checker := range f.checkers {
+		if trainer, ok := checker.(Trainer); ok {
+			if err := trainer.Train(ctx, in, class, delta); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// toInt 转换 HMGET 返回值，缺失时为 0
+func toInt(v interface{}) int64 {
+	s, ok := v.(string)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
+
+func joinReasons(reasons []string) string {
+	return strings.Join(reasons, "; ")
+}
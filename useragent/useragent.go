@@ -0,0 +1,112 @@
+// Package useragent 从 User-Agent 字符串中识别浏览器、操作系统和设备类型，用于浏览统计。
+package useragent
+
+import "strings"
+
+// FamilyOther 无法识别的浏览器或操作系统
+const FamilyOther = "Other"
+
+// 设备类型
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceOther   = "other"
+)
+
+// Agent 解析后的 User-Agent
+type Agent struct {
+	Browser string
+	OS      string
+	Device  string
+}
+
+// families 按匹配优先级排列：基于 Chromium 的浏览器和国内 App 内置浏览器的 UA 同时包含 Chrome / Safari 标识，必须先匹配
+var families = []struct {
+	marker string
+	family string
+}{
+	{"micromessenger", "WeChat"},
+	{" qq/", "QQ"},
+	{"mqqbrowser", "QQ Browser"},
+	{"qqbrowser", "QQ Browser"},
+	{"ucbrowser", "UC Browser"},
+	{"baiduboxapp", "Baidu"},
+	{"edg/", "Edge"},
+	{"edga/", "Edge"},
+	{"edgios/", "Edge"},
+	{"opr/", "Opera"},
+	{"opera", "Opera"},
+	{"samsungbrowser", "Samsung Internet"},
+	{"yabrowser", "Yandex"},
+	{"firefox/", "Firefox"},
+	{"fxios/", "Firefox"},
+	{"crios/", "Chrome"},
+	{"chrome/", "Chrome"},
+	{"chromium/", "Chrome"},
+	{"msie ", "IE"},
+	{"trident/", "IE"},
+	{"safari/", "Safari"},
+}
+
+// systems 按匹配优先级排列：iOS 的 UA 包含 "like Mac OS X"，鸿蒙和 ChromeOS 的 UA 可能包含 Android / Linux，必须先匹配
+var systems = []struct {
+	marker string
+	os     string
+}{
+	{"harmonyos", "HarmonyOS"},
+	{"iphone", "iOS"},
+	{"ipad", "iOS"},
+	{"ipod", "iOS"},
+	{"android", "Android"},
+	{"windows", "Windows"},
+	{"cros", "ChromeOS"},
+	{"mac os x", "macOS"},
+	{"macintosh", "macOS"},
+	{"linux", "Linux"},
+}
+
+// Family 返回浏览器名称，如 Chrome、Safari、WeChat，无法识别时返回 Other
+func Family(userAgent string) string {
+	return browser(strings.ToLower(userAgent))
+}
+
+// Parse 识别浏览器、操作系统和设备类型
+func Parse(userAgent string) Agent {
+	ua := strings.ToLower(userAgent)
+	system := FamilyOther
+	for _, s := range systems {
+		if strings.Contains(ua, s.marker) {
+			system = s.os
+			break
+		}
+	}
+	return Agent{Browser: browser(ua), OS: system, Device: device(ua, system)}
+}
+
+func browser(ua string) string {
+	for _, f := range families {
+		if strings.Contains(ua, f.marker) {
+			return f.family
+		}
+	}
+	return FamilyOther
+}
+
+// device 判断设备类型；iPadOS 13 起默认发送桌面版 Safari 的 UA，会被识别为 desktop
+func device(ua, system string) string {
+	switch {
+	case strings.Contains(ua, "ipad") || strings.Contains(ua, "tablet"):
+		return DeviceTablet
+	case system == "Android" && !strings.Contains(ua, "mobile"):
+		// Android 平板的 UA 不带 Mobile 标识
+		return DeviceTablet
+	case strings.Contains(ua, "mobi") || strings.Contains(ua, "iphone") || strings.Contains(ua, "ipod"):
+		return DeviceMobile
+	case system == "HarmonyOS" || system == "Android" || system == "iOS":
+		return DeviceMobile
+	case system == "Windows" || system == "macOS" || system == "Linux" || system == "ChromeOS":
+		return DeviceDesktop
+	}
+	return DeviceOther
+}